@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/franciscolkdo/guntar/tar"
+	"github.com/spf13/cobra"
+)
+
+var catLast bool
+
+// catCmd represents the cat command
+var catCmd = &cobra.Command{
+	Use:   "cat <archive> <path> [paths...]",
+	Short: "Print content of files in the archive",
+	Long: `Print content of files in the archive:
+
+Raw content of each file is written to stdout in the order of given paths, so it can be piped to jq, less or diff.
+The archive is read only until all paths are found, the first occurrence of a path appearing several times is printed
+(use --last to print the last one, like on extraction).
+`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, closer, err := openArchive(args[0])
+		if err != nil {
+			return err
+		}
+		defer closer.Close()
+		cmd.SilenceUsage = true // Usage doesn't help on a missing path
+		var opts []tar.CatOption
+		if catLast {
+			opts = append(opts, tar.WithLastOccurrence())
+		}
+		return tar.Cat(file, os.Stdout, args[1:], opts...)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(catCmd)
+	catCmd.Flags().BoolVar(&catLast, "last", false, "Print the last occurrence of paths appearing several times in the archive, like on extraction")
+}
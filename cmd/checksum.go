@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/franciscolkdo/guntar/tar"
+	"github.com/spf13/cobra"
+)
+
+var checksumAlgo, checksumCheck string
+
+// checkSums verify entries of the archive against the manifest file, results are printed like `sha256sum --check`
+func checkSums(cmd *cobra.Command, archive, manifest string) error {
+	if archive == stdinName && manifest == stdinName {
+		return fmt.Errorf("stdin can be used for only one of archive and checksums file")
+	}
+	f := os.Stdin
+	if manifest != stdinName {
+		var err error
+		if f, err = os.Open(manifest); err != nil {
+			return fmt.Errorf("failed to open checksums file: %s", err)
+		}
+		defer f.Close()
+	}
+	sums, err := tar.ParseSums(f)
+	if err != nil {
+		return err
+	}
+	file, closer, err := openArchive(archive)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	digests := map[string]string{} // Only the last occurrence of a path is hashed, like on extraction
+	err = tar.Checksums(file, checksumAlgo, func(n *tar.SimpleNode, digest string) error {
+		digests[n.GetPath()] = digest
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to hash archive entries: %s", err)
+	}
+
+	var failed, missing int
+	for _, s := range sums {
+		digest, ok := digests[path.Clean("/"+s.Path)]
+		switch {
+		case !ok:
+			missing++
+			fmt.Printf("%s: FAILED open or read\n", s.Path)
+		case digest != s.Digest:
+			failed++
+			fmt.Printf("%s: FAILED\n", s.Path)
+		default:
+			fmt.Printf("%s: OK\n", s.Path)
+		}
+	}
+	if missing > 0 {
+		fmt.Fprintf(os.Stderr, "guntar: WARNING: %d listed file(s) could not be found in the archive\n", missing)
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "guntar: WARNING: %d computed checksum(s) did NOT match\n", failed)
+	}
+	if missing > 0 || failed > 0 {
+		return exitCode(cmd, 1, nil)
+	}
+	return nil
+}
+
+// printSums write the manifest of archive entries to w, like `sha256sum` output
+func printSums(w io.Writer, archive string) error {
+	file, closer, err := openArchive(archive)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	err = tar.Checksums(file, checksumAlgo, func(n *tar.SimpleNode, digest string) error {
+		_, err := fmt.Fprintf(w, "%s  %s\n", digest, strings.TrimPrefix(n.GetPath(), "/"))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to hash archive entries: %s", err)
+	}
+	return nil
+}
+
+// checksumCmd represents the checksum command
+var checksumCmd = &cobra.Command{
+	Use:   "checksum <archive>",
+	Short: "Print or check checksums of archive entries",
+	Long: `Print or check checksums of archive entries:
+
+Content of each regular file is hashed while reading the archive, output is compatible with sha256sum (or sha512sum, b2sum, md5sum).
+When a path appears several times in the archive, only its last occurrence is hashed, like on extraction.
+With --check, entries are verified against an existing manifest without extracting them.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := tar.NewHash(checksumAlgo); err != nil {
+			return err
+		}
+		if len(checksumCheck) > 0 {
+			return checkSums(cmd, args[0], checksumCheck)
+		}
+		return printSums(os.Stdout, args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checksumCmd)
+	checksumCmd.Flags().StringVarP(&checksumAlgo, "algo", "a", "sha256", fmt.Sprintf("Hash algorithm, one of %v", tar.HashAlgorithms))
+	checksumCmd.Flags().StringVarP(&checksumCheck, "check", "c", "", "Verify entries against checksums read from this file (\"-\" for stdin)")
+}
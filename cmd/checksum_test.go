@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gtar "github.com/franciscolkdo/guntar/tar"
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksumDuplicateEntries(t *testing.T) {
+	archive := writeTestArchive(t, "release.tar", gtar.None, []test.File{
+		{Name: "app/conf", Mode: 0644, Body: "port=80"},
+		{Name: "app/conf", Mode: 0644, Body: "port=81"},
+		{Name: "app/old", Mode: 0644, Body: "old"},
+		{Name: "app/old", Mode: 0777, Type: tar.TypeSymlink, Linkname: "conf"},
+		{Name: "app/hard", Mode: 0644, Type: tar.TypeLink, Linkname: "app/conf"},
+	})
+
+	var manifest bytes.Buffer
+	require.Nil(t, printSums(&manifest, archive))
+	// sha256 of "port=81", app/old is a symlink on extraction, app/hard has the content of app/conf
+	assert.Equal(t, "7f0d5568c4a4680adf72d115b431d0e29db9666ca8383aaf9636e16151da30db  app/conf\n"+
+		"7f0d5568c4a4680adf72d115b431d0e29db9666ca8383aaf9636e16151da30db  app/hard\n", manifest.String())
+
+	sums := filepath.Join(t.TempDir(), "SHA256SUMS")
+	require.Nil(t, os.WriteFile(sums, manifest.Bytes(), 0644))
+	assert.Nil(t, checkSums(checksumCmd, archive, sums)) // Manifest printed from an archive matches it
+}
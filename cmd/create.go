@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/franciscolkdo/guntar/tar"
+	"github.com/spf13/cobra"
+)
+
+var excludes []string
+
+// createCmd represents the create command
+var createCmd = &cobra.Command{
+	Use:   "create -o <archive> <paths...>",
+	Short: "Create archive from files and directories",
+	Long: `Create a tar archive from files and directories:
+
+Directories are added recursively with modes, modification times, ownership and symlinks.
+Compression is chosen from the output extension (.gz, .xz, .zst) unless --compression is set, bzip2 can't be written.
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := parseExtractPath(); err != nil {
+			return err
+		}
+		if len(output) == 0 {
+			return fmt.Errorf("output archive is required")
+		}
+		c, err := tar.ParseCompression(compression)
+		if err != nil {
+			return err
+		}
+		if c == tar.Auto {
+			c = tar.CompressionFromName(output)
+		}
+		if err := tar.CheckWritable(c); err != nil { // Checked before creating the file, eg: for .bz2 extension
+			return fmt.Errorf("failed to create archive %s: %s", output, err)
+		}
+
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create archive: %s", err)
+		}
+		if err := writeArchive(file, args, c); err != nil {
+			os.Remove(output) // Don't leave a truncated archive
+			return fmt.Errorf("failed to create archive: %s", err)
+		}
+		return nil
+	},
+}
+
+// writeArchive write the archive of paths to file and close it
+func writeArchive(file *os.File, paths []string, c tar.Compression) error {
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := tar.Create(file, paths, tar.WithCompression(c), tar.WithExcludes(excludes...), tar.WithSkipFiles(fi)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(createCmd)
+	createCmd.Flags().StringVarP(&output, "output", "o", "", "Output archive file")
+	createCmd.Flags().StringArrayVarP(&excludes, "exclude", "x", []string{}, "Pattern of files to exclude (matched on base name and path)")
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franciscolkdo/guntar/tar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOutput(t *testing.T) {
+	src := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(src, "conf"), []byte("port=80"), 0644))
+	defer func() { output, compression = "", string(tar.Auto) }()
+
+	tests := []struct {
+		name  string
+		out   string
+		paths []string
+		err   string
+	}{
+		{name: "Gzip from extension", out: "out.tar.gz", paths: []string{src}},
+		{name: "Bzip2 is rejected", out: "out.tar.bz2", paths: []string{src}, err: "writing bzip2 compression is not supported"},
+		{name: "Tbz is rejected", out: "out.tbz", paths: []string{src}, err: "writing bzip2 compression is not supported"},
+		{name: "Missing input", out: "out.tar", paths: []string{filepath.Join(src, "missing")}, err: "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, compression = filepath.Join(t.TempDir(), tt.out), string(tar.Auto)
+			err := createCmd.RunE(createCmd, tt.paths)
+			if tt.err == "" {
+				require.Nil(t, err)
+				assert.FileExists(t, output)
+				return
+			}
+			assert.ErrorContains(t, err, tt.err)
+			assert.NoFileExists(t, output, "no archive is left on error")
+		})
+	}
+}
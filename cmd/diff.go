@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/franciscolkdo/guntar/tar"
+	"github.com/spf13/cobra"
+)
+
+var diffContent, diffJSON bool
+
+const diffContext = 3 // Number of lines around changes in unified diffs
+
+// diffEntry is the JSON description of an entry which differs between archives
+type diffEntry struct {
+	Path    string     `json:"path"`
+	Change  string     `json:"change"`
+	Reasons []string   `json:"reasons,omitempty"`
+	Old     *listEntry `json:"old,omitempty"`
+	New     *listEntry `json:"new,omitempty"`
+}
+
+// diffListEntry return the list description of a node, nil if node doesn't exist
+func diffListEntry(n *tar.SimpleNode) *listEntry {
+	if n == nil {
+		return nil
+	}
+	e := newListEntry(n, max(1, len(n.GetVersions())))
+	return &e
+}
+
+var changeCodes = map[tar.ChangeType]string{tar.Added: "A", tar.Removed: "D", tar.Modified: "M"}
+
+// summaryLine return a line like `git diff --name-status` output with the reasons of modification
+func summaryLine(c tar.Change[struct{}]) string {
+	line := changeCodes[c.Type] + "\t" + c.Path
+	if len(c.Reasons) > 0 {
+		line += "\t(" + strings.Join(c.Reasons, ", ") + ")"
+	}
+	return line
+}
+
+// contentDiff write the unified diff of regular files content, binary files are only reported
+func contentDiff(w io.Writer, c tar.Change[struct{}]) error {
+	oldName, newName := "/dev/null", "/dev/null"
+	var oldData, newData []byte
+	var err error
+	if c.Old != nil {
+		if !c.Old.IsRegular() {
+			return nil
+		}
+		oldName = "a" + c.Path
+		if oldData, err = c.Old.ReadData(); err != nil {
+			return fmt.Errorf("error on read %s: %s", c.Path, err)
+		}
+	}
+	if c.New != nil {
+		if !c.New.IsRegular() {
+			return nil
+		}
+		newName = "b" + c.Path
+		if newData, err = c.New.ReadData(); err != nil {
+			return fmt.Errorf("error on read %s: %s", c.Path, err)
+		}
+	}
+	if tar.IsBinary(oldData) || tar.IsBinary(newData) {
+		_, err = fmt.Fprintf(w, "Binary files %s and %s differ\n", oldName, newName)
+		return err
+	}
+	_, err = io.WriteString(w, tar.UnifiedDiff(oldName, newName, oldData, newData, diffContext))
+	return err
+}
+
+// printChanges write changes to w in the format chosen by flags
+func printChanges(w io.Writer, changes []tar.Change[struct{}]) error {
+	if diffJSON {
+		enc := json.NewEncoder(w)
+		for _, c := range changes {
+			err := enc.Encode(diffEntry{Path: c.Path, Change: string(c.Type), Reasons: c.Reasons, Old: diffListEntry(c.Old), New: diffListEntry(c.New)})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	count := map[tar.ChangeType]int{}
+	for _, c := range changes {
+		count[c.Type]++
+		if _, err := fmt.Fprintln(w, summaryLine(c)); err != nil {
+			return err
+		}
+		if diffContent {
+			if err := contentDiff(w, c); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d removed, %d modified\n", count[tar.Added], count[tar.Removed], count[tar.Modified])
+	return err
+}
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <archive a> <archive b>",
+	Short: "Show entries which differ between two archives",
+	Long: `Show entries which differ between two archives:
+
+Entries are added, removed or modified. An entry is modified when its type, size, mode, owner, link target
+or content (sha256 hash) differ. Use --content to print a unified diff of text files, or --json for scripts.
+Exit status is 0 if archives are the same, 1 if they differ and 2 on error.
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if args[0] == stdinName && args[1] == stdinName {
+			return exitCode(cmd, 2, fmt.Errorf("stdin can be used for only one archive"))
+		}
+		a, closerA, err := scanArchive(args[0])
+		if err != nil {
+			return exitCode(cmd, 2, err)
+		}
+		defer closerA.Close()
+		b, closerB, err := scanArchive(args[1])
+		if err != nil {
+			return exitCode(cmd, 2, err)
+		}
+		defer closerB.Close()
+		changes, err := tar.Diff(a, b)
+		if err != nil {
+			return exitCode(cmd, 2, fmt.Errorf("failed to compare archives: %s", err))
+		}
+		if err := printChanges(os.Stdout, changes); err != nil {
+			return exitCode(cmd, 2, err)
+		}
+		if len(changes) > 0 {
+			return exitCode(cmd, 1, nil)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().BoolVar(&diffContent, "content", false, "Print a unified diff of modified text files")
+	diffCmd.Flags().BoolVar(&diffJSON, "json", false, "Print one JSON object per changed entry")
+	diffCmd.MarkFlagsMutuallyExclusive("content", "json")
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/franciscolkdo/guntar/tar"
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintChanges(t *testing.T) {
+	a := scanTestArchive(t, []test.File{
+		{Name: "conf", Mode: 0644, Body: "port=80\nhost=a\n"},
+		{Name: "old", Mode: 0644, Body: "x\n"},
+		{Name: "same", Mode: 0644, Body: "s"},
+	})
+	b := scanTestArchive(t, []test.File{
+		{Name: "conf", Mode: 0600, Body: "port=81\nhost=a\n"},
+		{Name: "new", Mode: 0644, Body: "y\n"},
+		{Name: "same", Mode: 0644, Body: "s"},
+	})
+	changes, err := tar.Diff(a, b)
+	require.Nil(t, err)
+	defer func() { diffContent, diffJSON = false, false }()
+
+	tests := []struct {
+		name    string
+		content bool
+		json    bool
+		want    string
+	}{
+		{name: "summary", want: "M\t/conf\t(mode, content)\nA\t/new\nD\t/old\n1 added, 1 removed, 1 modified\n"},
+		{name: "content", content: true, want: "M\t/conf\t(mode, content)\n" +
+			"--- a/conf\n+++ b/conf\n@@ -1,2 +1,2 @@\n-port=80\n+port=81\n host=a\n" +
+			"A\t/new\n--- /dev/null\n+++ b/new\n@@ -0,0 +1 @@\n+y\n" +
+			"D\t/old\n--- a/old\n+++ /dev/null\n@@ -1 +0,0 @@\n-x\n" +
+			"1 added, 1 removed, 1 modified\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffContent, diffJSON = tt.content, tt.json
+			var out bytes.Buffer
+			require.Nil(t, printChanges(&out, changes))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		diffContent, diffJSON = false, true
+		var out bytes.Buffer
+		require.Nil(t, printChanges(&out, changes))
+		lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+		require.Len(t, lines, 3)
+		assert.Contains(t, string(lines[0]), `"path":"/conf","change":"modified","reasons":["mode","content"]`)
+		assert.Contains(t, string(lines[1]), `"path":"/new","change":"added"`)
+		assert.NotContains(t, string(lines[1]), `"old"`)
+		assert.Contains(t, string(lines[2]), `"path":"/old","change":"removed"`)
+	})
+}
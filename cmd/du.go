@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+	"github.com/franciscolkdo/guntar/tar"
+	"github.com/spf13/cobra"
+)
+
+var duDepth, duTop int
+var duJSON, duBytes bool
+
+// formatSize return size in bytes with --bytes, human readable otherwise
+func formatSize(size int64) string {
+	if duBytes {
+		return fmt.Sprint(size)
+	}
+	return strings.Replace(humanize.Bytes(uint64(size)), " ", "", 1)
+}
+
+// pruneDirs return a copy of du without directories deeper than depth
+func pruneDirs(du *tar.DirUsage, depth int) *tar.DirUsage {
+	pruned := *du
+	pruned.Dirs = nil
+	if depth > 0 {
+		for _, d := range du.Dirs {
+			pruned.Dirs = append(pruned.Dirs, pruneDirs(d, depth-1))
+		}
+	}
+	return &pruned
+}
+
+// writeDirs write a line per directory, sub directories follow their parent
+func writeDirs(w io.Writer, du *tar.DirUsage) {
+	fmt.Fprintf(w, "%s\t%d\t%s\n", formatSize(du.Size), du.Files, du.Path)
+	for _, d := range du.Dirs {
+		writeDirs(w, d)
+	}
+}
+
+// printUsage write the usage report in the format chosen by flags
+func printUsage(w io.Writer, usage tar.Usage) error {
+	usage.Root = pruneDirs(usage.Root, duDepth)
+	if duJSON {
+		return json.NewEncoder(w).Encode(usage)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SIZE\tFILES\tDIRECTORY")
+	writeDirs(tw, usage.Root)
+	fmt.Fprintln(tw, "\nSIZE\tFILE")
+	for _, f := range usage.Largest {
+		fmt.Fprintf(tw, "%s\t%s\n", formatSize(f.Size), f.Path)
+	}
+	fmt.Fprintln(tw, "\nSIZE\tFILES\tEXTENSION")
+	for _, e := range usage.Extensions {
+		ext := e.Ext
+		if ext == "" {
+			ext = "(none)"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", formatSize(e.Size), e.Files, ext)
+	}
+	return tw.Flush()
+}
+
+// duCmd represents the du command
+var duCmd = &cobra.Command{
+	Use:   "du <archive>",
+	Short: "Show sizes of directories, largest files and extensions of the archive",
+	Long: `Show sizes of directories, largest files and extensions of the archive:
+
+Sizes of files are aggregated recursively without extracting the archive, like du --apparent-size.
+Directories are printed up to --depth, largest first under their parent. The --top largest files and
+extensions are printed after directories. Use --json for scripts.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, closer, err := openArchive(args[0])
+		if err != nil {
+			return err
+		}
+		defer closer.Close()
+		root, err := tar.ScanHeaders(file, func(n *tar.SimpleNode) error { return nil })
+		if err != nil {
+			return fmt.Errorf("error on scanning tar file %s: %s", args[0], err)
+		}
+		return printUsage(os.Stdout, tar.DiskUsage(root, duTop))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(duCmd)
+	duCmd.Flags().IntVarP(&duDepth, "depth", "d", 1, "Print directories up to N levels below the root")
+	duCmd.Flags().IntVarP(&duTop, "top", "n", 10, "Number of largest files and extensions printed, 0 for all")
+	duCmd.Flags().BoolVar(&duJSON, "json", false, "Print the report as a JSON object")
+	duCmd.Flags().BoolVarP(&duBytes, "bytes", "b", false, "Print sizes in bytes")
+	duCmd.MarkFlagsMutuallyExclusive("json", "bytes")
+}
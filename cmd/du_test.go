@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+
+	gtar "github.com/franciscolkdo/guntar/tar"
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatSize(t *testing.T) {
+	defer func() { duBytes = false }()
+	tests := []struct {
+		size  int64
+		bytes bool
+		want  string
+	}{
+		{size: 0, want: "0B"},
+		{size: 999, want: "999B"},
+		{size: 1500, want: "1.5kB"},
+		{size: 3_000_000, want: "3.0MB"},
+		{size: 1500, bytes: true, want: "1500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			duBytes = tt.bytes
+			assert.Equal(t, tt.want, formatSize(tt.size))
+		})
+	}
+}
+
+func TestPrintUsage(t *testing.T) {
+	root := scanTestArchive(t, []test.File{
+		{Name: "app/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "app/lib/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "app/lib/a.so", Mode: 0644, Body: "0123456789"},
+		{Name: "app/main.go", Mode: 0644, Body: "12345"},
+		{Name: "README", Mode: 0644, Body: "1"},
+	})
+	defer func() { duDepth, duJSON, duBytes = 1, false, false }()
+
+	tests := []struct {
+		name  string
+		depth int
+		json  bool
+		want  string
+	}{
+		{name: "depth 1", depth: 1, want: "SIZE  FILES  DIRECTORY\n" +
+			"16    3      /\n" +
+			"15    2      /app\n" +
+			"\nSIZE  FILE\n10    /app/lib/a.so\n5     /app/main.go\n1     /README\n" +
+			"\nSIZE  FILES  EXTENSION\n10    1      .so\n5     1      .go\n1     1      (none)\n"},
+		{name: "depth 0", depth: 0, want: "SIZE  FILES  DIRECTORY\n" +
+			"16    3      /\n" +
+			"\nSIZE  FILE\n10    /app/lib/a.so\n5     /app/main.go\n1     /README\n" +
+			"\nSIZE  FILES  EXTENSION\n10    1      .so\n5     1      .go\n1     1      (none)\n"},
+		{name: "json", depth: 2, json: true, want: `{"root":{"path":"/","size":16,"files":3,"dirs":[{"path":"/app","size":15,"files":2,` +
+			`"dirs":[{"path":"/app/lib","size":10,"files":1}]}]},` +
+			`"largest":[{"path":"/app/lib/a.so","size":10},{"path":"/app/main.go","size":5}],` +
+			`"extensions":[{"ext":".so","size":10,"files":1},{"ext":".go","size":5,"files":1}]}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duDepth, duJSON, duBytes = tt.depth, tt.json, true
+			top := 0
+			if tt.json {
+				top = 2
+			}
+			var out bytes.Buffer
+			require.Nil(t, printUsage(&out, gtar.DiskUsage(root, top)))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
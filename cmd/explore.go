@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"io"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/franciscolkdo/guntar/terminal"
@@ -16,24 +16,54 @@ var exploreCmd = &cobra.Command{
 	Long: `Explore your tar archive in memory directly in your cli:
 
 You can browse, look into files and extract selected files/folders.
+With --image, a container image (docker save or OCI layout) is browsed as its merged root filesystem,
+each file shows the layer which last wrote it.
+With "-", the archive is read from stdin and kept in a temporary file while exploring, keys are read from the terminal.
 `,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkImageFlags(); err != nil {
+			return err
+		}
 		if err := parseExtractPath(); err != nil {
 			return err
 		}
 
-		file, err := os.Open(args[0])
+		var file io.Reader
+		opts := []tea.ProgramOption{tea.WithMouseCellMotion()}
+		if args[0] == stdinName {
+			// Keys are read from the terminal as stdin is the archive
+			tmp, cleanup, err := spillStdin()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			file = tmp
+			opts = append(opts, tea.WithInputTTY())
+		} else {
+			rc, err := openFile(args[0])
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			file = rc
+		}
+		archive, err := decompress(file)
 		if err != nil {
-			return fmt.Errorf("failed to open given file: %s", err)
+			return err
+		}
+		var term terminal.TerminalModel
+		if imageMode {
+			term, err = terminal.NewImage(archive, stackOptions(), output, extractOptions()...)
+		} else {
+			term, err = terminal.New(archive, output, extractOptions()...)
 		}
-		terminal, err := terminal.New(file, output)
 
 		if err != nil {
 			return fmt.Errorf("failed to create terminal: %s", err)
 		}
 
-		_, err = tea.NewProgram(terminal, tea.WithMouseCellMotion()).Run()
+		_, err = tea.NewProgram(term, opts...).Run()
 		if err != nil {
 			return fmt.Errorf("failed on quit program: %s", err)
 		}
@@ -42,6 +72,7 @@ You can browse, look into files and extract selected files/folders.
 }
 
 func init() {
-	exploreCmd.Flags().StringVarP(&output, "output", "o", "", "Output directory to extract archive")
+	addExtractFlags(exploreCmd)
+	addImageFlag(exploreCmd)
 	rootCmd.AddCommand(exploreCmd)
 }
@@ -2,45 +2,108 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/franciscolkdo/guntar/tar"
 	"github.com/spf13/cobra"
-	"golang.org/x/exp/slices"
 )
 
 var extractedFiles []string
+var matchBasename bool
+var occurrence int
+
+// validatePatterns return an error if one of the patterns is malformed
+func validatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := tar.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %s: %s", p, err)
+		}
+	}
+	return nil
+}
+
+// isSelected return true if node must be extracted based on -e flags.
+// With --basename, patterns are matched against the name of the node only.
+func isSelected(n *tar.SimpleNode) bool {
+	if len(extractedFiles) == 0 {
+		return true
+	}
+	if matchBasename {
+		for _, p := range extractedFiles {
+			if ok, _ := tar.Match(p, n.Name()); ok {
+				return true
+			}
+		}
+		return false
+	}
+	ok, _ := tar.MatchAny(extractedFiles, n.GetPath()) // Patterns are validated before
+	return ok
+}
 
 // extractCmd represents the extract command
 var extractCmd = &cobra.Command{
 	Use:   "extract <archive>",
 	Short: "Extract archive",
-	Args:  cobra.ExactArgs(1),
+	Long: `Extract archive:
+
+Entries are written while reading the archive, memory use doesn't depend on archive size.
+With --jobs greater than 1, the archive is scanned first then files are written concurrently,
+content of compressed or piped archives is held in memory in this mode.
+When a path appears several times in the archive, the last occurrence wins unless --occurrence is set.
+With --image, the root filesystem of a container image (docker save or OCI layout) is extracted from its stacked layers.
+`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		file, err := os.Open(args[0])
-		if err != nil {
-			return fmt.Errorf("failed to open given file: %s", err)
+		if err := checkImageFlags(); err != nil {
+			return err
 		}
-		node, err := tar.Scan(file, func(n *tar.SimpleNode) error { return nil })
-		if err != nil {
-			return fmt.Errorf("failed to list archive: %s", err)
+		if err := validatePatterns(extractedFiles); err != nil {
+			return err
 		}
-
 		if err := parseExtractPath(); err != nil {
 			return err
 		}
+		if jobs > 1 || imageMode {
+			return extractTree(args[0])
+		}
+		file, closer, err := openArchive(args[0])
+		if err != nil {
+			return err
+		}
+		defer closer.Close()
 
-		return tar.Extract(node, output, func(n *tar.SimpleNode) bool {
-			if len(extractedFiles) != 0 {
-				return !slices.Contains(extractedFiles, n.Name())
+		occurrences := map[string]int{}
+		return tar.ExtractStream(file, output, func(n *tar.SimpleNode) bool {
+			if occurrence > 0 {
+				occurrences[n.GetPath()]++
+				if occurrences[n.GetPath()] != occurrence {
+					return true
+				}
 			}
-			return false
-		})
+			return !isSelected(n)
+		}, extractOptions()...)
 	},
 }
 
+// extractTree scan the whole archive then extract selected nodes with concurrent workers
+func extractTree(name string) error {
+	root, closer, err := scanArchive(name)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	return tar.Extract(root, output, func(n *tar.SimpleNode) bool {
+		if occurrence > 0 && n.SelectVersion(occurrence-1) != nil {
+			return true
+		}
+		return !isSelected(n)
+	}, extractOptions()...)
+}
+
 func init() {
 	rootCmd.AddCommand(extractCmd)
-	extractCmd.Flags().StringVarP(&output, "output", "o", "", "Output directory to extract archive")
-	extractCmd.Flags().StringArrayVarP(&extractedFiles, "ext", "e", []string{}, "List of files to extract")
+	addExtractFlags(extractCmd)
+	addImageFlag(extractCmd)
+	extractCmd.Flags().StringArrayVarP(&extractedFiles, "ext", "e", []string{}, "Path or glob of files to extract (eg: etc/nginx, etc/**/*.conf), directories are extracted recursively")
+	extractCmd.Flags().BoolVar(&matchBasename, "basename", false, "Match -e patterns against file names only, at any depth")
+	extractCmd.Flags().IntVar(&occurrence, "occurrence", 0, "Extract only the Nth occurrence (starting at 1) of paths appearing several times in the archive")
 }
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/franciscolkdo/guntar/tar"
+	"github.com/spf13/cobra"
+)
+
+var grepIgnoreCase, grepLineNumber, grepFilesWithMatches, grepCount bool
+var grepAfter, grepBefore, grepContext int
+var grepIncludes, grepExcludes []string
+
+// matchEntry return true if archive path of n, one of its parents or its name matches one of the patterns
+func matchEntry(patterns []string, n *tar.SimpleNode) bool {
+	if ok, _ := tar.MatchAny(patterns, n.GetPath()); ok { // Patterns are validated before
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := tar.Match(p, n.Name()); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// grepPrinter return the callback printing matching lines of a file in the format chosen by flags
+func grepPrinter() func(*tar.SimpleNode, []tar.GrepLine) error {
+	lastPath, lastLine := "", 0
+	return func(n *tar.SimpleNode, lines []tar.GrepLine) error {
+		switch {
+		case grepFilesWithMatches:
+			fmt.Println(n.GetPath())
+			return nil
+		case grepCount:
+			count := 0
+			for _, l := range lines {
+				if !l.Context {
+					count++
+				}
+			}
+			fmt.Printf("%s:%d\n", n.GetPath(), count)
+			return nil
+		}
+		for _, l := range lines {
+			// Separate groups of lines which are not contiguous like grep does
+			if (grepBefore > 0 || grepAfter > 0) && lastPath != "" && (lastPath != n.GetPath() || lastLine+1 != l.Number) {
+				fmt.Println("--")
+			}
+			lastPath, lastLine = n.GetPath(), l.Number
+			sep := ":"
+			if l.Context {
+				sep = "-"
+			}
+			if grepLineNumber {
+				fmt.Printf("%s%s%d%s%s\n", n.GetPath(), sep, l.Number, sep, l.Text)
+			} else {
+				fmt.Printf("%s%s%s\n", n.GetPath(), sep, l.Text)
+			}
+		}
+		return nil
+	}
+}
+
+// grepCmd represents the grep command
+var grepCmd = &cobra.Command{
+	Use:   "grep <pattern> <archive>",
+	Short: "Search a pattern in files of the archive",
+	Long: `Search a pattern in files of the archive:
+
+Pattern is a Go regular expression (https://pkg.go.dev/regexp/syntax), matching lines are printed as path:line.
+Files are searched while reading the archive, binary files (with a NUL byte in their first 8000 bytes) are skipped.
+--include and --exclude globs are matched against the archive path and the name of files.
+Exit status is 0 if a line matches, 1 if none matches and 2 on error.
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pattern := args[0]
+		if grepIgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return exitCode(cmd, 2, fmt.Errorf("invalid pattern %s: %s", args[0], err))
+		}
+		if err := validatePatterns(append(grepIncludes, grepExcludes...)); err != nil {
+			return exitCode(cmd, 2, err)
+		}
+		if !cmd.Flags().Changed("after-context") {
+			grepAfter = grepContext
+		}
+		if !cmd.Flags().Changed("before-context") {
+			grepBefore = grepContext
+		}
+		file, closer, err := openArchive(args[1])
+		if err != nil {
+			return exitCode(cmd, 2, err)
+		}
+		defer closer.Close()
+
+		found := false
+		printLines := grepPrinter()
+		err = tar.Grep(file, re, func(n *tar.SimpleNode) bool {
+			return (len(grepIncludes) > 0 && !matchEntry(grepIncludes, n)) || matchEntry(grepExcludes, n)
+		}, func(n *tar.SimpleNode, lines []tar.GrepLine) error {
+			found = true
+			return printLines(n, lines)
+		}, tar.WithContext(grepBefore, grepAfter))
+		if err != nil {
+			return exitCode(cmd, 2, fmt.Errorf("failed to search archive: %s", err))
+		}
+		if !found {
+			return exitCode(cmd, 1, nil)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(grepCmd)
+	grepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "Ignore case distinctions in pattern and content")
+	grepCmd.Flags().BoolVarP(&grepLineNumber, "line-number", "n", false, "Print line number of matching lines")
+	grepCmd.Flags().BoolVarP(&grepFilesWithMatches, "files-with-matches", "l", false, "Print only paths of files with a match")
+	grepCmd.Flags().BoolVarP(&grepCount, "count", "c", false, "Print only the number of matching lines of files with a match")
+	grepCmd.Flags().IntVarP(&grepAfter, "after-context", "A", 0, "Print N lines after matching lines")
+	grepCmd.Flags().IntVarP(&grepBefore, "before-context", "B", 0, "Print N lines before matching lines")
+	grepCmd.Flags().IntVarP(&grepContext, "context", "C", 0, "Print N lines before and after matching lines")
+	grepCmd.Flags().StringArrayVar(&grepIncludes, "include", []string{}, "Search only files matching this glob (eg: '*.conf', 'etc/**')")
+	grepCmd.Flags().StringArrayVar(&grepExcludes, "exclude", []string{}, "Skip files matching this glob")
+	grepCmd.MarkFlagsMutuallyExclusive("files-with-matches", "count")
+}
@@ -1,34 +1,251 @@
 package cmd
 
 import (
+	"archive/tar"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"text/template"
+	"time"
 
-	"github.com/franciscolkdo/guntar/tar"
+	gtar "github.com/franciscolkdo/guntar/tar"
 	"github.com/spf13/cobra"
 )
 
+var listLong, listJSON, listCSV, listAllVersions bool
+var listFormat string
+
+// listEntry is the description of an archive entry used by list output formats
+type listEntry struct {
+	Path       string            `json:"path"`
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	Mode       string            `json:"mode"`
+	Uid        int               `json:"uid"`
+	Gid        int               `json:"gid"`
+	Uname      string            `json:"uname"`
+	Gname      string            `json:"gname"`
+	Size       int64             `json:"size"`
+	ModTime    time.Time         `json:"modTime"`
+	AccessTime *time.Time        `json:"accessTime,omitempty"`
+	ChangeTime *time.Time        `json:"changeTime,omitempty"`
+	Linkname   string            `json:"linkname,omitempty"`
+	Devmajor   int64             `json:"devmajor,omitempty"`
+	Devminor   int64             `json:"devminor,omitempty"`
+	Format     string            `json:"format"`
+	Version    int               `json:"version"`  // Occurrence of the path in the archive, starting at 1
+	Versions   int               `json:"versions"` // Number of occurrences of the path in the archive
+	PAXRecords map[string]string `json:"paxRecords,omitempty"`
+}
+
+var typeNames = map[byte]string{
+	tar.TypeReg:     "file",
+	tar.TypeRegA:    "file",
+	tar.TypeLink:    "hardlink",
+	tar.TypeSymlink: "symlink",
+	tar.TypeChar:    "char",
+	tar.TypeBlock:   "block",
+	tar.TypeDir:     "dir",
+	tar.TypeFifo:    "fifo",
+	tar.TypeCont:    "cont",
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func newListEntry(n *gtar.SimpleNode, versions int) listEntry {
+	h := n.GetHeader()
+	typ, ok := typeNames[h.Typeflag]
+	if !ok {
+		typ = string(h.Typeflag)
+	}
+	return listEntry{
+		Path:       n.GetPath(),
+		Name:       h.Name,
+		Type:       typ,
+		Mode:       fmt.Sprintf("%04o", h.Mode&07777),
+		Uid:        h.Uid,
+		Gid:        h.Gid,
+		Uname:      h.Uname,
+		Gname:      h.Gname,
+		Size:       h.Size,
+		ModTime:    h.ModTime,
+		AccessTime: timePtr(h.AccessTime),
+		ChangeTime: timePtr(h.ChangeTime),
+		Linkname:   h.Linkname,
+		Devmajor:   h.Devmajor,
+		Devminor:   h.Devminor,
+		Format:     h.Format.String(),
+		PAXRecords: h.PAXRecords,
+		Version:    n.GetVersion() + 1,
+		Versions:   versions,
+	}
+}
+
+// versionSuffix return the version of an entry if its path appears several times and all versions are listed
+func versionSuffix(n *gtar.SimpleNode, versions int) string {
+	if !listAllVersions || versions < 2 {
+		return ""
+	}
+	return fmt.Sprintf(" (version %d/%d)", n.GetVersion()+1, versions)
+}
+
+// modeString return permissions of h like `tar tvf` (eg: rwsr-xr-x, rwxrwxrwt), setuid, setgid and sticky bits
+// are shown in place of execute bits, in upper case when the execute bit is not set
+func modeString(h *tar.Header) string {
+	const rwx = "rwxrwxrwx"
+	perm := []byte("---------")
+	for i := range perm {
+		if h.Mode&(1<<uint(8-i)) != 0 {
+			perm[i] = rwx[i]
+		}
+	}
+	special := []struct {
+		bit      int64
+		pos      int
+		set, off byte
+	}{{04000, 2, 's', 'S'}, {02000, 5, 's', 'S'}, {01000, 8, 't', 'T'}}
+	for _, s := range special {
+		if h.Mode&s.bit == 0 {
+			continue
+		}
+		if perm[s.pos] == '-' {
+			perm[s.pos] = s.off
+		} else {
+			perm[s.pos] = s.set
+		}
+	}
+	return string(perm)
+}
+
+// longLine return a line like `tar tvf` output: mode owner/group size date name
+func longLine(n *gtar.SimpleNode, versions int) string {
+	h := n.GetHeader()
+	typ := map[byte]byte{tar.TypeDir: 'd', tar.TypeSymlink: 'l', tar.TypeLink: 'h', tar.TypeChar: 'c', tar.TypeBlock: 'b', tar.TypeFifo: 'p'}[h.Typeflag]
+	if typ == 0 {
+		typ = '-'
+	}
+	owner, group := h.Uname, h.Gname
+	if owner == "" {
+		owner = strconv.Itoa(h.Uid)
+	}
+	if group == "" {
+		group = strconv.Itoa(h.Gid)
+	}
+	line := fmt.Sprintf("%c%s %s/%s %8d %s %s", typ, modeString(h), owner, group, h.Size, h.ModTime.Format("2006-01-02 15:04"), n.GetPath())
+	switch h.Typeflag {
+	case tar.TypeSymlink:
+		line += " -> " + h.Linkname
+	case tar.TypeLink:
+		line += " link to " + h.Linkname
+	}
+	return line + versionSuffix(n, versions)
+}
+
+// listPrinter return a function writing an entry to w in the format chosen by flags, and a function to flush output
+func listPrinter(w io.Writer) (func(*gtar.SimpleNode, int) error, func() error, error) {
+	noFlush := func() error { return nil }
+	switch {
+	case listLong:
+		return func(n *gtar.SimpleNode, versions int) error {
+			_, err := fmt.Fprintln(w, longLine(n, versions))
+			return err
+		}, noFlush, nil
+	case listJSON:
+		enc := json.NewEncoder(w)
+		return func(n *gtar.SimpleNode, versions int) error { return enc.Encode(newListEntry(n, versions)) }, noFlush, nil
+	case listCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"path", "type", "mode", "uid", "gid", "uname", "gname", "size", "modTime", "linkname", "version"}); err != nil {
+			return nil, nil, err
+		}
+		return func(n *gtar.SimpleNode, versions int) error {
+				e := newListEntry(n, versions)
+				return cw.Write([]string{e.Path, e.Type, e.Mode, strconv.Itoa(e.Uid), strconv.Itoa(e.Gid), e.Uname, e.Gname,
+					strconv.FormatInt(e.Size, 10), e.ModTime.Format(time.RFC3339), e.Linkname, strconv.Itoa(e.Version)})
+			}, func() error {
+				cw.Flush()
+				return cw.Error()
+			}, nil
+	case len(listFormat) > 0:
+		tmpl, err := template.New("format").Parse(listFormat)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid format: %s", err)
+		}
+		return func(n *gtar.SimpleNode, versions int) error {
+			if err := tmpl.Execute(w, newListEntry(n, versions)); err != nil {
+				return err
+			}
+			_, err := fmt.Fprintln(w)
+			return err
+		}, noFlush, nil
+	}
+	return func(n *gtar.SimpleNode, versions int) error {
+		_, err := fmt.Fprintln(w, n.GetPath()+versionSuffix(n, versions))
+		return err
+	}, noFlush, nil
+}
+
 // listCmd represents the list command
 var listCmd = &cobra.Command{
 	Use:   "list <archive file>",
 	Short: "List all files in current archive",
-	Args:  cobra.ExactArgs(1),
+	Long: `List all files in current archive:
+
+By default only paths are printed, use --long, --json, --csv or --format to get entries details.
+Fields available in --format template: .Path .Name .Type .Mode .Uid .Gid .Uname .Gname .Size .ModTime .Linkname .Version .Versions
+When a path appears several times in the archive, the last occurrence is listed unless --all-versions is set.
+`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		file, err := os.Open(args[0])
+		printEntry, flush, err := listPrinter(os.Stdout)
+		if err != nil {
+			return err
+		}
+		file, closer, err := openArchive(args[0])
 		if err != nil {
-			return fmt.Errorf("failed to open given file: %s", err)
+			return err
 		}
-		ls, err := tar.List(file)
+		defer closer.Close()
+		root, err := gtar.ScanHeaders(file, func(n *gtar.SimpleNode) error { return nil })
 		if err != nil {
 			return fmt.Errorf("failed to list archive: %s", err)
 		}
-		for _, f := range ls {
-			fmt.Println(f)
+		err = root.OnNestedChildren(func(n *gtar.SimpleNode) error {
+			if n.IsImplicit() {
+				return nil
+			}
+			versions := n.GetVersions()
+			if !listAllVersions || versions == nil {
+				return printEntry(n, max(1, len(versions)))
+			}
+			for _, v := range versions {
+				if err := printEntry(v, len(versions)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list archive: %s", err)
 		}
-		return nil
+		return flush()
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().BoolVarP(&listLong, "long", "l", false, "Print mode, owner, size, modification time and link target of entries")
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "Print one JSON object per entry")
+	listCmd.Flags().BoolVar(&listCSV, "csv", false, "Print entries as CSV")
+	listCmd.Flags().StringVar(&listFormat, "format", "", "Print entries with a Go template (eg: '{{.Path}} {{.Size}}')")
+	listCmd.Flags().BoolVar(&listAllVersions, "all-versions", false, "List all occurrences of paths appearing several times in the archive")
+	listCmd.MarkFlagsMutuallyExclusive("long", "json", "csv", "format")
 }
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+	"time"
+
+	gtar "github.com/franciscolkdo/guntar/tar"
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanTestArchive return the tree of an archive of files
+func scanTestArchive(t *testing.T, files []test.File) *gtar.SimpleNode {
+	root, err := gtar.Scan(bytes.NewReader(test.CreateArchive(t, files).Bytes()), func(n *gtar.SimpleNode) error { return nil })
+	require.Nil(t, err)
+	return root
+}
+
+func TestModeString(t *testing.T) {
+	tests := []struct {
+		mode int64
+		want string
+	}{
+		{mode: 0644, want: "rw-r--r--"},
+		{mode: 0755, want: "rwxr-xr-x"},
+		{mode: 04755, want: "rwsr-xr-x"},
+		{mode: 04644, want: "rwSr--r--"},
+		{mode: 02755, want: "rwxr-sr-x"},
+		{mode: 02644, want: "rw-r-Sr--"},
+		{mode: 01777, want: "rwxrwxrwt"},
+		{mode: 01776, want: "rwxrwxrwT"},
+		{mode: 07777, want: "rwsrwsrwt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, modeString(&tar.Header{Mode: tt.mode}))
+		})
+	}
+}
+
+func TestListPrinter(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	root := scanTestArchive(t, []test.File{
+		{Name: "bin/", Mode: 0755, Type: tar.TypeDir, ModTime: date},
+		{Name: "bin/su", Mode: 04755, Body: "elf", ModTime: date},
+		{Name: "tmp/", Mode: 01777, Type: tar.TypeDir, ModTime: date},
+		{Name: "sh", Mode: 0777, Type: tar.TypeSymlink, Linkname: "bin/su", ModTime: date},
+	})
+	defer func() { listLong, listCSV, listFormat = false, false, "" }()
+
+	tests := []struct {
+		name   string
+		long   bool
+		csv    bool
+		format string
+		want   string
+	}{
+		{name: "paths", want: "/bin\n/bin/su\n/tmp\n/sh\n"},
+		{name: "long", long: true, want: "drwxr-xr-x 0/0        0 2024-03-01 12:30 /bin\n" +
+			"-rwsr-xr-x 0/0        3 2024-03-01 12:30 /bin/su\n" +
+			"drwxrwxrwt 0/0        0 2024-03-01 12:30 /tmp\n" +
+			"lrwxrwxrwx 0/0        0 2024-03-01 12:30 /sh -> bin/su\n"},
+		{name: "csv", csv: true, want: "path,type,mode,uid,gid,uname,gname,size,modTime,linkname,version\n" +
+			"/bin,dir,0755,0,0,,,0,2024-03-01T12:30:00Z,,1\n" +
+			"/bin/su,file,4755,0,0,,,3,2024-03-01T12:30:00Z,,1\n" +
+			"/tmp,dir,1777,0,0,,,0,2024-03-01T12:30:00Z,,1\n" +
+			"/sh,symlink,0777,0,0,,,0,2024-03-01T12:30:00Z,bin/su,1\n"},
+		{name: "format", format: "{{.Type}} {{.Path}} {{.Size}}", want: "dir /bin 0\nfile /bin/su 3\ndir /tmp 0\nsymlink /sh 0\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listLong, listCSV, listFormat = tt.long, tt.csv, tt.format
+			var out bytes.Buffer
+			printEntry, flush, err := listPrinter(&out)
+			require.Nil(t, err)
+			require.Nil(t, root.OnNestedChildren(func(n *gtar.SimpleNode) error { return printEntry(n, 1) }))
+			require.Nil(t, flush())
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+
+	t.Run("invalid format", func(t *testing.T) {
+		listLong, listCSV, listFormat = false, false, "{{.Path"
+		_, _, err := listPrinter(&bytes.Buffer{})
+		assert.NotNil(t, err)
+	})
+}
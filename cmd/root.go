@@ -1,15 +1,179 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/franciscolkdo/guntar/tar"
 	"github.com/spf13/cobra"
 )
 
 var output string
+var compression string
+var preservePermissions, preserveTimes, sameOwner, allowUnsafePaths bool
+var jobs int
+var imageMode, rawWhiteouts bool
+
+// addExtractFlags add flags used to configure extraction on disk
+func addExtractFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output directory to extract archive")
+	cmd.Flags().BoolVar(&preservePermissions, "preserve-permissions", false, "Apply permissions from archive to extracted files and directories")
+	cmd.Flags().BoolVar(&preserveTimes, "preserve-times", false, "Restore modification time of extracted files and directories")
+	cmd.Flags().BoolVar(&sameOwner, "same-owner", false, "Restore owner of extracted files and directories (root only)")
+	cmd.Flags().BoolVar(&allowUnsafePaths, "allow-unsafe-paths", false, "Allow absolute paths, parent paths and symlinks leading outside of output directory (trusted archives only)")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", 1, "Number of files written concurrently")
+}
+
+// addImageFlag add the flag reading archive as a container image
+func addImageFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&imageMode, "image", false, "Read the archive as a container image (docker save or OCI layout) and use its merged root filesystem")
+	cmd.Flags().BoolVar(&rawWhiteouts, "raw-whiteouts", false, "Keep whiteout entries (.wh.*) of image layers in the merged root filesystem")
+}
+
+// checkImageFlags return an error if image flags are set without --image
+func checkImageFlags() error {
+	if rawWhiteouts && !imageMode {
+		return fmt.Errorf("--raw-whiteouts requires --image")
+	}
+	return nil
+}
+
+// stackOptions return tar stack options from flags
+func stackOptions() []tar.StackOption {
+	var opts []tar.StackOption
+	if rawWhiteouts {
+		opts = append(opts, tar.WithRawWhiteouts())
+	}
+	return opts
+}
+
+// extractOptions return tar extract options from flags
+func extractOptions() []tar.ExtractOption {
+	var opts []tar.ExtractOption
+	if preservePermissions {
+		opts = append(opts, tar.WithPreservePermissions())
+	}
+	if preserveTimes {
+		opts = append(opts, tar.WithPreserveTimes())
+	}
+	if sameOwner {
+		opts = append(opts, tar.WithSameOwner())
+	}
+	if allowUnsafePaths {
+		opts = append(opts, tar.WithUnsafePaths())
+	}
+	if jobs > 1 {
+		opts = append(opts, tar.WithJobs(jobs))
+	}
+	return opts
+}
+
+// stdinName is the archive name used to read the archive from stdin
+const stdinName = "-"
+
+// openFile open the given archive file, stdin if name is "-". Returned file must be closed once the archive is read.
+// HTTP(S) URLs are read with range requests when the server supports them, streamed otherwise.
+func openFile(name string) (io.ReadCloser, error) {
+	if name == stdinName {
+		return os.Stdin, nil
+	}
+	if tar.IsURL(name) {
+		r, err := tar.OpenURL(nil, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open given url: %s", err)
+		}
+		return r, nil
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open given file: %s", err)
+	}
+	return file, nil
+}
+
+// decompress wrap file with the decompressor set by --compression flag.
+// With auto, the file is returned as is so tar.Scan can detect it. With none, the file is kept as is too,
+// content is read lazily from it when possible, but compression is not detected anymore.
+func decompress(file io.Reader) (io.Reader, error) {
+	c, err := tar.ParseCompression(compression)
+	if err != nil {
+		return nil, err
+	}
+	switch c {
+	case tar.Auto:
+		return file, nil
+	case tar.None:
+		return tar.Uncompressed(file), nil
+	}
+	r, err := tar.Decompress(file, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress given file: %s", err)
+	}
+	return tar.Uncompressed(r), nil
+}
+
+// openArchive open the given archive file ("-" for stdin, or URL) and wrap it with the decompressor set by --compression flag.
+// Returned closer close the file, it must be called once the archive is read.
+func openArchive(name string) (io.Reader, io.Closer, error) {
+	file, err := openFile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := decompress(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return r, file, nil
+}
+
+// spillStdin copy stdin to a temporary file, so the archive can be read at random positions.
+// Returned function close and remove the file.
+func spillStdin() (*os.File, func(), error) {
+	tmp, err := os.CreateTemp("", "guntar-stdin-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temporary file: %s", err)
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	if _, err := io.Copy(tmp, os.Stdin); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to read stdin: %s", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to read stdin: %s", err)
+	}
+	return tmp, cleanup, nil
+}
+
+// scanArchive open and scan the given archive, file contents are read lazily when possible.
+// With --image, the root filesystem of the container image is returned.
+// Returned closer close the archive file, it must be called once the tree is not used anymore.
+func scanArchive(name string) (*tar.SimpleNode, io.Closer, error) {
+	file, closer, err := openArchive(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	noop := func(n *tar.SimpleNode) error { return nil }
+	var root *tar.SimpleNode
+	if imageMode {
+		root, err = tar.ScanImage(file, noop, stackOptions()...)
+	} else {
+		root, err = tar.Scan(file, noop)
+	}
+	if err != nil {
+		closer.Close()
+		return nil, nil, fmt.Errorf("error on scanning tar file %s: %s", name, err)
+	}
+	return root, closer, nil
+}
 
 func parseExtractPath() error {
 	if strings.HasPrefix(output, "~/") {
@@ -29,11 +193,45 @@ var rootCmd = &cobra.Command{
 	Long: `Guntar is a cli experience for tar archives:
 
 It can read tar archive and allow you to browse, read and extract files directly in memory.
+Use "-" as archive name to read it from stdin, or an HTTP(S) URL to read it from a server.
+Compressed archives (gzip, bzip2, xz, zstd) and zip archives are detected automatically.
 `,
 }
 
+func init() {
+	rootCmd.PersistentFlags().StringVar(&compression, "compression", string(tar.Auto), fmt.Sprintf("Compression of the archive, one of %v", tar.Compressions))
+}
+
+// exitError end the program with a specific exit code, err is printed if not nil
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e exitError) Unwrap() error { return e.err }
+
+// exitCode return an error exiting with code, nothing is printed if err is nil
+func exitCode(cmd *cobra.Command, code int, err error) error {
+	if err == nil {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+	}
+	return exitError{code: code, err: err}
+}
+
 func Execute() {
 	err := rootCmd.Execute()
+	var exitErr exitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.code)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franciscolkdo/guntar/tar"
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestArchive write a tar archive of files in a temporary directory with given compression
+func writeTestArchive(t *testing.T, name string, c tar.Compression, files []test.File) string {
+	p := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(p)
+	require.Nil(t, err)
+	defer f.Close()
+	w, err := tar.Compress(f, c)
+	require.Nil(t, err)
+	_, err = w.Write(test.CreateArchive(t, files).Bytes())
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+	return p
+}
+
+func TestCompressionFlag(t *testing.T) {
+	files := []test.File{{Name: "app/conf", Mode: 0644, Body: "port=80"}}
+	gz := writeTestArchive(t, "app.tar.gz", tar.Gzip, files)
+	plain := writeTestArchive(t, "app.tar", tar.None, files)
+	defer func() { compression = string(tar.Auto) }()
+
+	tests := []struct {
+		compression string
+		archive     string
+		valid       bool
+	}{
+		{compression: "auto", archive: gz, valid: true},
+		{compression: "auto", archive: plain, valid: true},
+		{compression: "gzip", archive: gz, valid: true},
+		{compression: "none", archive: plain, valid: true},
+		{compression: "none", archive: gz, valid: false}, // Compression is not detected anymore
+		{compression: "gzip", archive: plain, valid: false},
+		{compression: "lz4", archive: plain, valid: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.compression+" "+filepath.Base(tt.archive), func(t *testing.T) {
+			compression = tt.compression
+			root, closer, err := scanArchive(tt.archive)
+			if !tt.valid {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			defer closer.Close()
+			require.NotNil(t, root.Find("app/conf"))
+			assert.Equal(t, "port=80", string(root.Find("app/conf").GetData()))
+		})
+	}
+}
+
+func TestOpenArchiveClose(t *testing.T) {
+	archive := writeTestArchive(t, "app.tar", tar.None, []test.File{{Name: "app/conf", Mode: 0644, Body: "port=80"}})
+	r, closer, err := openArchive(archive)
+	require.Nil(t, err)
+	require.Nil(t, closer.Close())
+	_, err = tar.List(r)
+	assert.ErrorContains(t, err, os.ErrClosed.Error())
+
+	_, _, err = openArchive(filepath.Join(t.TempDir(), "missing.tar"))
+	assert.NotNil(t, err)
+}
+
+func TestImageFlags(t *testing.T) {
+	layer := test.CreateArchive(t, []test.File{{Name: "etc/motd", Mode: 0644, Body: "hello"}}).String()
+	image := writeTestArchive(t, "image.tar", tar.None, []test.File{
+		{Name: "manifest.json", Mode: 0644, Body: `[{"Layers": ["1111111111111111/layer.tar"]}]`},
+		{Name: "1111111111111111/layer.tar", Mode: 0644, Body: layer},
+	})
+	defer func() { imageMode, rawWhiteouts, output = false, false, "" }()
+
+	t.Run("Image alone", func(t *testing.T) {
+		out := t.TempDir()
+		rootCmd.SetArgs([]string{"extract", image, "--image", "-o", out})
+		require.Nil(t, rootCmd.Execute())
+		data, err := os.ReadFile(filepath.Join(out, "guntar_extracted/etc/motd"))
+		require.Nil(t, err)
+		assert.Equal(t, "hello", string(data))
+	})
+
+	t.Run("Raw whiteouts without image", func(t *testing.T) {
+		imageMode = false
+		rootCmd.SetArgs([]string{"extract", image, "--raw-whiteouts", "-o", t.TempDir()})
+		assert.ErrorContains(t, rootCmd.Execute(), "--raw-whiteouts requires --image")
+	})
+}
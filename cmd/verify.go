@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/franciscolkdo/guntar/tar"
+	"github.com/spf13/cobra"
+)
+
+var verifyDir string
+var verifyIgnore []string
+var verifyJSON, verifyQuiet bool
+
+// verifyEntry is the JSON description of an entry which doesn't match the file system
+type verifyEntry struct {
+	Path    string   `json:"path"`
+	Status  string   `json:"status"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// printMismatches write mismatches to w in the format chosen by flags
+func printMismatches(w io.Writer, mismatches []tar.Mismatch[struct{}]) error {
+	if verifyQuiet {
+		return nil
+	}
+	if verifyJSON {
+		enc := json.NewEncoder(w)
+		for _, m := range mismatches {
+			if err := enc.Encode(verifyEntry{Path: m.Path, Status: string(m.Type), Reasons: m.Reasons}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	count := map[tar.ChangeType]int{}
+	for _, m := range mismatches {
+		count[m.Type]++
+		line := string(m.Type) + "\t" + m.Path
+		if len(m.Reasons) > 0 {
+			line += "\t(" + strings.Join(m.Reasons, ", ") + ")"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d missing, %d modified, %d extra\n", count[tar.Missing], count[tar.Modified], count[tar.Extra])
+	return err
+}
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify <archive>",
+	Short: "Check that a directory matches the archive",
+	Long: `Check that a directory matches the archive:
+
+Each entry of the archive is compared to the file system (existence, type, size, mode, modification time,
+content and link target), files in the directory which are not in the archive are reported as extra.
+Use --ignore to skip checks, eg: --ignore mode,mtime when the archive was extracted without preserving them.
+Exit status is 0 if the directory matches, 1 if it doesn't and 2 on error.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, check := range verifyIgnore {
+			if err := tar.ParseCheck(check); err != nil {
+				return exitCode(cmd, 2, err)
+			}
+		}
+		root, closer, err := scanArchive(args[0])
+		if err != nil {
+			return exitCode(cmd, 2, err)
+		}
+		defer closer.Close()
+		mismatches, err := tar.Verify(root, verifyDir, tar.WithIgnoredChecks(verifyIgnore...))
+		if err != nil {
+			return exitCode(cmd, 2, fmt.Errorf("failed to verify %s: %s", verifyDir, err))
+		}
+		if err := printMismatches(os.Stdout, mismatches); err != nil {
+			return exitCode(cmd, 2, err)
+		}
+		if len(mismatches) > 0 {
+			return exitCode(cmd, 1, nil)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().StringVarP(&verifyDir, "dir", "d", ".", "Directory to compare with the archive")
+	verifyCmd.Flags().StringSliceVar(&verifyIgnore, "ignore", []string{}, fmt.Sprintf("Checks to skip, from %v", tar.Checks))
+	verifyCmd.Flags().BoolVar(&verifyJSON, "json", false, "Print one JSON object per mismatching entry")
+	verifyCmd.Flags().BoolVarP(&verifyQuiet, "quiet", "q", false, "Print nothing, only set the exit status")
+	verifyCmd.MarkFlagsMutuallyExclusive("json", "quiet")
+}
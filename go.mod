@@ -1,6 +1,6 @@
 module github.com/franciscolkdo/guntar
 
-go 1.20
+go 1.22
 
 require (
 	github.com/charmbracelet/bubbles v0.18.0
@@ -8,8 +8,11 @@ require (
 	github.com/charmbracelet/glamour v0.7.0
 	github.com/charmbracelet/lipgloss v0.12.1
 	github.com/dustin/go-humanize v1.0.1
+	github.com/klauspost/compress v1.18.0
 	github.com/spf13/cobra v1.8.1
 	github.com/stretchr/testify v1.9.0
+	github.com/ulikunitz/xz v0.5.9
+	golang.org/x/crypto v0.25.0
 	golang.org/x/exp v0.0.0-20231006140011-7918f672742d
 )
 
@@ -0,0 +1,153 @@
+package tar
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ArchiveReader read the entries of an archive one by one, entries of all formats are described with a tar header
+type ArchiveReader interface {
+	// Next advance to the next entry of the archive and return its header, io.EOF at the end of the archive
+	Next() (*tar.Header, error)
+	// Read read content of the current entry
+	Read(p []byte) (int, error)
+	// Lazy return a function opening content of the current entry at any time,
+	// nil if content can only be read with Read before the next call to Next
+	Lazy() func() (io.ReadCloser, error)
+}
+
+// seekable return a section reader over r from its current position when it can be read at random positions (eg: *os.File),
+// nil otherwise
+func seekable(r io.Reader) *io.SectionReader {
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		return nil
+	}
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return nil
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil { // Pipes are files too but can't seek
+		return nil
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil
+	}
+	if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+		return nil
+	}
+	return io.NewSectionReader(ra, start, end-start)
+}
+
+// uncompressed is a reader whose compression must not be detected (see Uncompressed)
+type uncompressed struct{ io.Reader }
+
+// Uncompressed mark r as an uncompressed archive: compression is not detected when reading it,
+// eg: to read a tar archive starting with a gzip magic number, or the output of Decompress.
+func Uncompressed(r io.Reader) io.Reader {
+	return uncompressed{r}
+}
+
+// NewArchiveReader return a reader of the tar or zip archive in r, format and compression are detected from the first bytes
+// unless r is wrapped with Uncompressed. When r implements io.ReaderAt and io.Seeker (eg: *os.File), contents of
+// uncompressed tar and zip archives are read lazily from r. A zip archive read from a stream is loaded in memory,
+// its index is at the end.
+func NewArchiveReader(r io.Reader) (ArchiveReader, error) {
+	u, raw := r.(uncompressed)
+	if raw {
+		r = u.Reader
+	}
+	if sr := seekable(r); sr != nil {
+		head := make([]byte, sniffLen)
+		n, err := sr.ReadAt(head, 0)
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("on detecting archive format: %s", err)
+		}
+		if isZip(head[:n]) {
+			return newZipReader(sr, sr.Size())
+		}
+		if raw || DetectCompression(head[:n]) == None {
+			return &tarReader{tr: tar.NewReader(sr), sr: sr}, nil
+		}
+		r = sr
+	}
+
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("on detecting archive format: %s", err)
+	}
+	if isZip(head) {
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, fmt.Errorf("on reading zip archive: %s", err)
+		}
+		return newZipReader(bytes.NewReader(data), int64(len(data)))
+	}
+	if raw {
+		return &tarReader{tr: tar.NewReader(br)}, nil
+	}
+	dr, err := Decompress(br, Auto)
+	if err != nil {
+		return nil, err
+	}
+	return &tarReader{tr: tar.NewReader(dr)}, nil
+}
+
+// tarReader read entries of a tar archive
+type tarReader struct {
+	tr     *tar.Reader
+	sr     *io.SectionReader // sr is the uncompressed archive when it can be read at random positions, nil otherwise
+	header *tar.Header
+	offset int64 // offset of current entry content in sr
+}
+
+// Next return the header of the next entry, global PAX headers (eg: pax_global_header of `git archive`) are skipped
+// as they only hold metadata for the following entries
+func (t *tarReader) Next() (*tar.Header, error) {
+	header, err := t.tr.Next()
+	for err == nil && header.Typeflag == tar.TypeXGlobalHeader {
+		header, err = t.tr.Next()
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.header = header
+	if t.sr != nil {
+		if t.offset, err = t.sr.Seek(0, io.SeekCurrent); err != nil {
+			return nil, fmt.Errorf("on reading archive offset: %s", err)
+		}
+	}
+	return header, nil
+}
+
+func (t *tarReader) Read(p []byte) (int, error) { return t.tr.Read(p) }
+
+func (t *tarReader) Lazy() func() (io.ReadCloser, error) {
+	if t.sr == nil || isSparse(t.header) {
+		return nil
+	}
+	sr, offset, size := t.sr, t.offset, t.header.Size
+	return func() (io.ReadCloser, error) {
+		return sectionReadCloser{io.NewSectionReader(sr, offset, size)}, nil
+	}
+}
+
+// isSparse return true if entry data is not stored contiguously in the archive
+func isSparse(header *tar.Header) bool {
+	if header.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for k := range header.PAXRecords {
+		if strings.HasPrefix(k, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package tar
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+)
+
+var errNotRegular = errors.New("not a regular file")
+
+// errNotKept is the content of files not kept in memory when reading a stream, they can't be read by hardlinks
+var errNotKept = errors.New("content of hardlink target is not kept when reading a stream")
+
+// catResult is the content of a requested file read before its turn, or the reason it can't be written
+type catResult struct {
+	data []byte
+	open func() (io.ReadCloser, error) // open read content from the archive, nil if data is in memory
+	err  error
+}
+
+// write copy content of the file to w
+func (res catResult) write(w io.Writer) error {
+	if res.open == nil {
+		_, err := w.Write(res.data)
+		return err
+	}
+	rc, err := res.open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(w, rc)
+	return err
+}
+
+// CatOption configure Cat
+type CatOption func(*catConfig)
+
+type catConfig struct {
+	lastOccurrence bool
+}
+
+// WithLastOccurrence print the last occurrence of a path appearing several times, like on extraction.
+// The whole archive is read before writing files.
+func WithLastOccurrence() CatOption {
+	return func(c *catConfig) { c.lastOccurrence = true }
+}
+
+// Cat write content of regular files at given archive paths to w, in the order of paths.
+// The archive is read only until all files are found, a path appearing several times is read at its first occurrence
+// (see WithLastOccurrence). When r can be read at random positions (see Scan), files found before their turn are read
+// from r on their turn, otherwise they are kept in memory. Missing paths and non regular files are reported
+// with a fs.PathError (fs.ErrNotExist for missing ones) once all other files are written.
+// Hardlinks are read from their target, which must still be in memory when r is a stream (requested after the link).
+func Cat(r io.Reader, w io.Writer, paths []string, opts ...CatOption) error {
+	var cfg catConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ar, err := NewArchiveReader(r)
+	if err != nil {
+		return err
+	}
+	order := make([]string, 0, len(paths))
+	requested := map[string]bool{}
+	for _, p := range paths {
+		p = path.Clean("/" + p)
+		order = append(order, p)
+		requested[p] = true
+	}
+	if cfg.lastOccurrence {
+		return catLast(ar, w, order, requested)
+	}
+	return catFirst(ar, w, order, requested)
+}
+
+// linkContent return the content of the hardlink target, read before the link in the archive
+func linkContent(contents map[string]catResult, header *tar.Header) catResult {
+	if res, ok := contents[filepath.Join("/", header.Linkname)]; ok {
+		return res
+	}
+	return catResult{err: fmt.Errorf("hardlink target %s is not a regular file", header.Linkname)}
+}
+
+// catFirst write first occurrences of paths, as soon as it's their turn
+func catFirst(ar ArchiveReader, w io.Writer, order []string, remaining map[string]bool) error {
+	results := map[string]catResult{}
+	contents := map[string]catResult{} // contents are regular files read so far, hardlinks are resolved with them
+	var errs []error
+	next := 0 // Index in order of the next file to write
+	write := func(p string, res catResult) error {
+		next++
+		if res.err != nil {
+			errs = append(errs, &fs.PathError{Op: "cat", Path: p, Err: res.err})
+			return nil
+		}
+		if err := res.write(w); err != nil {
+			return fmt.Errorf("error on read %s: %s", p, err)
+		}
+		return nil
+	}
+	flush := func() error {
+		for next < len(order) {
+			res, ok := results[order[next]]
+			if !ok {
+				return nil
+			}
+			if err := write(order[next], res); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for len(remaining) > 0 {
+		header, err := ar.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("on reading archive %s", err)
+		}
+		p := filepath.Join("/", header.Name) // Sanitize path
+		var res catResult
+		streamed := false
+		switch {
+		case header.Typeflag == tar.TypeLink: // Target is before the link in the archive
+			res = linkContent(contents, header)
+		case !newNode[struct{}](header, p).IsRegular():
+			res = catResult{err: errNotRegular}
+		case remaining[p] && order[next] == p && !slices.Contains(order[next+1:], p): // Its turn and not needed later, no need to keep it
+			res = catResult{open: func() (io.ReadCloser, error) { return io.NopCloser(ar), nil }}
+			streamed = true
+		case ar.Lazy() != nil:
+			res = catResult{open: ar.Lazy()}
+		case !remaining[p]:
+			res = catResult{err: errNotKept}
+		default:
+			data, err := io.ReadAll(ar)
+			if err != nil {
+				return fmt.Errorf("error on read %s: %s", p, err)
+			}
+			res = catResult{data: data}
+		}
+		switch {
+		case streamed && ar.Lazy() != nil:
+			contents[p] = catResult{open: ar.Lazy()}
+		case streamed:
+			contents[p] = catResult{err: errNotKept}
+		case res.err == nil || res.err == errNotKept:
+			contents[p] = res
+		default:
+			delete(contents, p)
+		}
+		if remaining[p] {
+			delete(remaining, p)
+			results[p] = res
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	for next < len(order) {
+		res, ok := results[order[next]]
+		if !ok {
+			res.err = fs.ErrNotExist
+		}
+		if err := write(order[next], res); err != nil {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// catLast read the whole archive and write last occurrences of paths
+func catLast(ar ArchiveReader, w io.Writer, order []string, requested map[string]bool) error {
+	results := map[string]catResult{}
+	contents := map[string]catResult{} // contents are regular files read so far, hardlinks are resolved with them
+	for {
+		header, err := ar.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("on reading archive %s", err)
+		}
+		p := filepath.Join("/", header.Name) // Sanitize path
+		var res catResult
+		switch {
+		case header.Typeflag == tar.TypeLink: // Target is before the link in the archive
+			res = linkContent(contents, header)
+		case !newNode[struct{}](header, p).IsRegular():
+			res = catResult{err: errNotRegular}
+		case ar.Lazy() != nil:
+			res = catResult{open: ar.Lazy()}
+		case requested[p]:
+			data, err := io.ReadAll(ar)
+			if err != nil {
+				return fmt.Errorf("error on read %s: %s", p, err)
+			}
+			res = catResult{data: data}
+		default:
+			res = catResult{err: errNotKept}
+		}
+		if res.err == nil || res.err == errNotKept {
+			contents[p] = res
+		} else {
+			delete(contents, p)
+		}
+		if requested[p] {
+			results[p] = res
+		}
+	}
+
+	var errs []error
+	for _, p := range order {
+		res, ok := results[p]
+		switch {
+		case !ok:
+			errs = append(errs, &fs.PathError{Op: "cat", Path: p, Err: fs.ErrNotExist})
+		case res.err != nil:
+			errs = append(errs, &fs.PathError{Op: "cat", Path: p, Err: res.err})
+		default:
+			if err := res.write(w); err != nil {
+				return fmt.Errorf("error on read %s: %s", p, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,110 @@
+package tar
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCat(t *testing.T) {
+	files := []test.File{
+		{Name: "./etc/", Mode: 0755, Body: ""},
+		{Name: "./etc/a.txt", Mode: 0644, Body: "a\n"},
+		{Name: "./etc/b.txt", Mode: 0644, Body: "b\n"},
+		{Name: "./etc/big.bin", Mode: 0644, Body: string(bytes.Repeat([]byte("x"), 4096))},
+	}
+	archive := test.CreateArchive(t, files).Bytes()
+
+	t.Run("Paths order", func(t *testing.T) {
+		var out bytes.Buffer
+		require.Nil(t, Cat(bytes.NewReader(archive), &out, []string{"etc/b.txt", "/etc/a.txt", "./etc/b.txt"}))
+		assert.Equal(t, "b\na\nb\n", out.String())
+	})
+
+	t.Run("Stop reading once found", func(t *testing.T) {
+		var out bytes.Buffer
+		truncated := archive[:len(archive)-3000] // Reading the big file would fail
+		require.Nil(t, Cat(bytes.NewReader(truncated), &out, []string{"etc/a.txt"}))
+		assert.Equal(t, "a\n", out.String())
+		assert.NotNil(t, Cat(bytes.NewReader(truncated), &out, []string{"etc/big.bin"}))
+		// The whole archive is read to find last occurrences
+		assert.NotNil(t, Cat(bytes.NewReader(truncated), &out, []string{"etc/a.txt"}, WithLastOccurrence()))
+	})
+
+	t.Run("Missing and non regular files", func(t *testing.T) {
+		var out bytes.Buffer
+		err := Cat(bytes.NewReader(archive), &out, []string{"etc/missing", "etc", "etc/a.txt"})
+		assert.Equal(t, "a\n", out.String())
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+		assert.ErrorContains(t, err, "cat /etc/missing: file does not exist")
+		assert.ErrorContains(t, err, "cat /etc: not a regular file")
+	})
+}
+
+func TestCatDuplicateEntries(t *testing.T) {
+	files := []test.File{
+		{Name: "app/conf", Mode: 0644, Body: "version: 1"},
+		{Name: "app/other", Mode: 0644, Body: "other"},
+		{Name: "app/conf", Mode: 0644, Body: "version: 2"},
+		{Name: "app/link", Mode: 0644, Body: "file"},
+		{Name: "app/link", Mode: 0777, Type: tar.TypeSymlink, Linkname: "conf"},
+	}
+	for name, r := range map[string]func() io.Reader{
+		"stream":   func() io.Reader { return test.CreateArchive(t, files) },
+		"seekable": func() io.Reader { return bytes.NewReader(test.CreateArchive(t, files).Bytes()) },
+	} {
+		t.Run("First occurrence from "+name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.Nil(t, Cat(r(), &out, []string{"app/conf", "app/link"}))
+			assert.Equal(t, "version: 1file", out.String())
+		})
+
+		t.Run("Last occurrence from "+name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Cat(r(), &out, []string{"app/conf", "app/link"}, WithLastOccurrence())
+			assert.Equal(t, "version: 2", out.String())
+			assert.ErrorContains(t, err, "cat /app/link: not a regular file")
+		})
+	}
+}
+
+func TestCatHardlinks(t *testing.T) {
+	files := []test.File{
+		{Name: "src/a.txt", Mode: 0644, Body: "a\n"},
+		{Name: "src/hard", Mode: 0644, Type: tar.TypeLink, Linkname: "src/a.txt"},
+		{Name: "src/dangling", Mode: 0644, Type: tar.TypeLink, Linkname: "src/missing"},
+	}
+	t.Run("Target read from seekable archive", func(t *testing.T) {
+		for _, opts := range [][]CatOption{nil, {WithLastOccurrence()}} {
+			var out bytes.Buffer
+			require.Nil(t, Cat(bytes.NewReader(test.CreateArchive(t, files).Bytes()), &out, []string{"src/a.txt", "src/hard"}, opts...))
+			assert.Equal(t, "a\na\n", out.String())
+		}
+	})
+
+	t.Run("Target requested from stream", func(t *testing.T) {
+		var out bytes.Buffer
+		require.Nil(t, Cat(test.CreateArchive(t, files), &out, []string{"src/hard", "src/a.txt"}))
+		require.Nil(t, Cat(test.CreateArchive(t, files), &out, []string{"src/a.txt", "src/hard"}, WithLastOccurrence()))
+		assert.Equal(t, "a\na\na\na\n", out.String())
+	})
+
+	t.Run("Target not available", func(t *testing.T) {
+		var out bytes.Buffer
+		err := Cat(test.CreateArchive(t, files), &out, []string{"src/hard", "src/dangling"})
+		assert.Empty(t, out.String())
+		assert.ErrorContains(t, err, "cat /src/hard: content of hardlink target is not kept when reading a stream")
+		assert.ErrorContains(t, err, "cat /src/dangling: hardlink target src/missing is not a regular file")
+
+		err = Cat(test.CreateArchive(t, files), &out, []string{"src/a.txt", "src/hard"}) // Target written without keeping it
+		assert.Equal(t, "a\n", out.String())
+		assert.ErrorContains(t, err, "cat /src/hard: content of hardlink target is not kept when reading a stream")
+	})
+}
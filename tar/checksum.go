@@ -0,0 +1,119 @@
+package tar
+
+import (
+	"archive/tar"
+	"bufio"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// HashAlgorithms list all supported algorithms to hash entries content
+var HashAlgorithms = []string{"sha256", "sha512", "blake2b", "md5"}
+
+// NewHash return a hash for given algorithm, blake2b is the 512 bits version used by b2sum
+func NewHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	case "blake2b":
+		return blake2b.New512(nil)
+	case "md5":
+		return md5.New(), nil
+	}
+	return nil, fmt.Errorf("unknown hash algorithm %q, expected one of %v", algo, HashAlgorithms)
+}
+
+// Checksums stream the archive and call cb with the hex digest of each regular file content.
+// Hardlinks are reported with the digest of their target, like the file created on extraction.
+// When a path appears several times in the archive, only its last occurrence is reported like on extraction
+// (none if it's not a regular file), so cb is called once the whole archive is read, in order of first occurrence of paths.
+func Checksums(r io.Reader, algo string, cb func(n *SimpleNode, digest string) error) error {
+	if _, err := NewHash(algo); err != nil {
+		return err
+	}
+	type sum struct {
+		node   *SimpleNode
+		digest string
+	}
+	var paths []string
+	sums := map[string]*sum{}
+	err := Stream(r, func(n *SimpleNode) error {
+		if _, ok := sums[n.GetPath()]; !ok {
+			paths = append(paths, n.GetPath())
+		}
+		if n.header.Typeflag == tar.TypeLink { // Target is before the link in the archive
+			sums[n.GetPath()] = nil
+			if target := sums[filepath.Join("/", n.header.Linkname)]; target != nil {
+				sums[n.GetPath()] = &sum{node: n, digest: target.digest}
+			}
+			return nil
+		}
+		if !n.IsRegular() {
+			sums[n.GetPath()] = nil
+			return nil
+		}
+		h, _ := NewHash(algo)
+		rc, err := n.Open()
+		if err != nil {
+			return fmt.Errorf("error on read %s: %s", n.GetPath(), err)
+		}
+		defer rc.Close()
+		if _, err := io.Copy(h, rc); err != nil {
+			return fmt.Errorf("error on read %s: %s", n.GetPath(), err)
+		}
+		sums[n.GetPath()] = &sum{node: n, digest: hex.EncodeToString(h.Sum(nil))}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if s := sums[p]; s != nil {
+			if err := cb(s.node, s.digest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Sum is a line of a checksum manifest
+type Sum struct {
+	Digest string
+	Path   string
+}
+
+// ParseSums read a manifest in sha256sum format: "<hex digest>  <path>" per line ("*" before path marks binary mode)
+func ParseSums(r io.Reader) ([]Sum, error) {
+	var sums []Sum
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		digest, path, ok := strings.Cut(text, " ")
+		if _, err := hex.DecodeString(digest); !ok || err != nil || digest == "" {
+			return nil, fmt.Errorf("invalid checksum line %d: %q", line, text)
+		}
+		if !strings.HasPrefix(path, " ") && !strings.HasPrefix(path, "*") {
+			return nil, fmt.Errorf("invalid checksum line %d: %q", line, text)
+		}
+		sums = append(sums, Sum{Digest: strings.ToLower(digest), Path: path[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error on read checksums: %s", err)
+	}
+	return sums, nil
+}
@@ -0,0 +1,87 @@
+package tar
+
+import (
+	"archive/tar"
+	"strings"
+	"testing"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksums(t *testing.T) {
+	files := []test.File{
+		{Name: "./app/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "./app/hello.txt", Mode: 0644, Body: "hello\n"},
+		{Name: "./app/link", Mode: 0777, Type: tar.TypeSymlink, Linkname: "hello.txt"},
+		{Name: "./app/empty", Mode: 0644, Body: ""},
+	}
+	tests := []struct {
+		algo     string
+		expected map[string]string
+	}{
+		{algo: "sha256", expected: map[string]string{
+			"/app/hello.txt": "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+			"/app/empty":     "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		}},
+		{algo: "md5", expected: map[string]string{
+			"/app/hello.txt": "b1946ac92492d2347c6235b4d2611184",
+			"/app/empty":     "d41d8cd98f00b204e9800998ecf8427e",
+		}},
+		{algo: "blake2b", expected: map[string]string{
+			"/app/hello.txt": "f60ce482e5cc1229f39d71313171a8d9f4ca3a87d066bf4b205effb528192a75f14f3271e2c1a90e1de53f275b4d4793eef2f5e31ea90d2ce29d2e481c36435f",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algo, func(t *testing.T) {
+			digests := map[string]string{}
+			err := Checksums(test.CreateArchive(t, files), tt.algo, func(n *SimpleNode, digest string) error {
+				digests[n.GetPath()] = digest
+				return nil
+			})
+			require.Nil(t, err)
+			for path, expected := range tt.expected {
+				assert.Equal(t, expected, digests[path])
+			}
+			assert.Len(t, digests, 2)
+		})
+	}
+
+	t.Run("Unknown algorithm", func(t *testing.T) {
+		err := Checksums(test.CreateArchive(t, files), "crc32", func(n *SimpleNode, digest string) error { return nil })
+		assert.ErrorContains(t, err, "unknown hash algorithm")
+	})
+}
+
+func TestChecksumsDuplicateEntries(t *testing.T) {
+	var paths, digests []string
+	err := Checksums(test.CreateArchive(t, []test.File{
+		{Name: "a", Mode: 0644, Body: "v1"},
+		{Name: "b", Mode: 0644, Body: "b"},
+		{Name: "a", Mode: 0644, Body: "hello\n"},
+		{Name: "c", Mode: 0644, Body: "c"},
+		{Name: "c", Mode: 0777, Type: tar.TypeSymlink, Linkname: "a"},
+		{Name: "./hard", Mode: 0644, Type: tar.TypeLink, Linkname: "./a"},
+		{Name: "dangling", Mode: 0644, Type: tar.TypeLink, Linkname: "missing"},
+	}), "sha256", func(n *SimpleNode, digest string) error {
+		paths = append(paths, n.GetPath())
+		digests = append(digests, digest)
+		return nil
+	})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"/a", "/b", "/hard"}, paths) // c is a symlink on extraction
+	assert.Equal(t, "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03", digests[0])
+	assert.Equal(t, digests[0], digests[2], "hardlink has the digest of its target")
+}
+
+func TestParseSums(t *testing.T) {
+	sums, err := ParseSums(strings.NewReader("# comment\nABCD  app/hello.txt\n0123 *app/with space.bin\r\n\n"))
+	require.Nil(t, err)
+	assert.Equal(t, []Sum{{Digest: "abcd", Path: "app/hello.txt"}, {Digest: "0123", Path: "app/with space.bin"}}, sums)
+
+	_, err = ParseSums(strings.NewReader("abcd app/hello.txt\n"))
+	assert.ErrorContains(t, err, "invalid checksum line 1")
+	_, err = ParseSums(strings.NewReader("xyz  app/hello.txt\n"))
+	assert.ErrorContains(t, err, "invalid checksum line 1")
+}
@@ -0,0 +1,172 @@
+package tar
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Compression is the compression format wrapping a tar archive
+type Compression string
+
+const (
+	Auto  Compression = "auto" // Auto detect compression from magic bytes
+	None  Compression = "none"
+	Gzip  Compression = "gzip"
+	Bzip2 Compression = "bzip2"
+	Xz    Compression = "xz"
+	Zstd  Compression = "zstd"
+)
+
+// Compressions list all supported compression formats
+var Compressions = []Compression{Auto, None, Gzip, Bzip2, Xz, Zstd}
+
+const (
+	tarMagicOffset = 257 // Offset of "ustar" magic in a tar header block
+	sniffLen       = tarMagicOffset + 5
+)
+
+var magics = []struct {
+	compression Compression
+	magic       []byte
+}{
+	{Gzip, []byte{0x1f, 0x8b}},
+	{Bzip2, []byte("BZh")},
+	{Xz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{Zstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// ParseCompression return the Compression matching given name
+func ParseCompression(name string) (Compression, error) {
+	for _, c := range Compressions {
+		if string(c) == name {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown compression %q, expected one of %v", name, Compressions)
+}
+
+// DetectCompression return the compression format from the first bytes of a stream.
+// An uncompressed tar archive is recognized first so an entry name can't be confused with a magic number.
+func DetectCompression(head []byte) Compression {
+	if len(head) >= sniffLen && bytes.HasPrefix(head[tarMagicOffset:], []byte("ustar")) {
+		return None
+	}
+	for _, m := range magics {
+		if bytes.HasPrefix(head, m.magic) {
+			return m.compression
+		}
+	}
+	return None
+}
+
+// Decompress wrap reader with the decompressor of given compression.
+// With Auto, compression is detected by sniffing the magic bytes of the stream.
+func Decompress(r io.Reader, c Compression) (io.Reader, error) {
+	if c == Auto {
+		br := bufio.NewReaderSize(r, sniffLen)
+		head, err := br.Peek(sniffLen)
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("on detecting compression: %s", err)
+		}
+		c = DetectCompression(head)
+		r = br
+	}
+
+	switch c {
+	case None:
+		return r, nil
+	case Gzip:
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("on reading gzip stream: %s", err)
+		}
+		return zr, nil
+	case Bzip2:
+		return bzip2.NewReader(r), nil
+	case Xz:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("on reading xz stream: %s", err)
+		}
+		return xr, nil
+	case Zstd:
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("on reading zstd stream: %s", err)
+		}
+		return zr, nil
+	}
+	return nil, fmt.Errorf("unknown compression %q", c)
+}
+
+var extensions = []struct {
+	compression Compression
+	suffixes    []string
+}{
+	{Gzip, []string{".gz", ".tgz"}},
+	{Bzip2, []string{".bz2", ".tbz", ".tbz2"}},
+	{Xz, []string{".xz", ".txz"}},
+	{Zstd, []string{".zst", ".tzst"}},
+}
+
+// CompressionFromName return the compression matching the extension of a file name, None if there is no known extension
+func CompressionFromName(name string) Compression {
+	for _, ext := range extensions {
+		for _, suffix := range ext.suffixes {
+			if strings.HasSuffix(strings.ToLower(name), suffix) {
+				return ext.compression
+			}
+		}
+	}
+	return None
+}
+
+// WritableCompressions list compression formats supported by Compress
+var WritableCompressions = []Compression{None, Gzip, Xz, Zstd}
+
+// CheckWritable return an error if archives can't be written with given compression
+func CheckWritable(c Compression) error {
+	for _, wc := range WritableCompressions {
+		if wc == c {
+			return nil
+		}
+	}
+	return fmt.Errorf("writing %s compression is not supported, expected one of %v", c, WritableCompressions)
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// Compress wrap writer with the compressor of given compression (see WritableCompressions),
+// returned writer must be closed to flush compressed data, it doesn't close w.
+func Compress(w io.Writer, c Compression) (io.WriteCloser, error) {
+	if err := CheckWritable(c); err != nil {
+		return nil, err
+	}
+	switch c {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Xz:
+		xw, err := xz.NewWriter(w)
+		if err != nil {
+			return nil, fmt.Errorf("on writing xz stream: %s", err)
+		}
+		return xw, nil
+	case Zstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, fmt.Errorf("on writing zstd stream: %s", err)
+		}
+		return zw, nil
+	}
+	return nopWriteCloser{w}, nil // None
+}
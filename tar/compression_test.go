@@ -0,0 +1,100 @@
+package tar
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compress(t *testing.T, c Compression, data []byte) *bytes.Buffer {
+	var buf bytes.Buffer
+	w, err := Compress(&buf, c)
+	require.Nil(t, err)
+	_, err = w.Write(data)
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+	return &buf
+}
+
+func TestDetectCompression(t *testing.T) {
+	archive := test.CreateArchive(t, []test.File{{Name: "BZh.txt", Mode: 0600, Body: "not bzip2"}}).Bytes()
+	tests := []struct {
+		name     string
+		head     []byte
+		expected Compression
+	}{
+		{name: "Plain tar starting like bzip2", head: archive, expected: None},
+		{name: "Gzip magic", head: []byte{0x1f, 0x8b, 0x08}, expected: Gzip},
+		{name: "Bzip2 magic", head: []byte("BZh91AY&SY"), expected: Bzip2},
+		{name: "Xz magic", head: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00, 0x00}, expected: Xz},
+		{name: "Zstd magic", head: []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, expected: Zstd},
+		{name: "Unknown bytes", head: []byte("hello"), expected: None},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectCompression(tt.head))
+		})
+	}
+}
+
+func TestScanCompressedArchive(t *testing.T) {
+	files := []test.File{
+		{Name: "./test/", Mode: 0755, Body: ""},
+		{Name: "./test/hello.txt", Mode: 0600, Body: "world"},
+	}
+	archive := test.CreateArchive(t, files).Bytes()
+	for _, c := range []Compression{Gzip, Xz, Zstd} {
+		t.Run(string(c), func(t *testing.T) {
+			root, err := Scan(compress(t, c, archive), func(n *SimpleNode) error { return nil })
+			require.Nil(t, err)
+			require.Equal(t, 1, root.LenChildren())
+			hello := root.GetChildren()[0].GetChildren()[0]
+			assert.Equal(t, "/test/hello.txt", hello.GetPath())
+			assert.Equal(t, []byte("world"), hello.GetData())
+		})
+		t.Run(string(c)+" forced", func(t *testing.T) {
+			r, err := Decompress(compress(t, c, archive), c)
+			require.Nil(t, err)
+			res, err := List(r)
+			require.Nil(t, err)
+			assert.Len(t, res, len(files))
+		})
+	}
+
+	t.Run("Uncompressed skips detection", func(t *testing.T) {
+		_, err := Scan(Uncompressed(compress(t, Gzip, archive)), func(n *SimpleNode) error { return nil })
+		assert.NotNil(t, err, "gzip stream must not be decompressed")
+		root, err := Scan(Uncompressed(bytes.NewReader(archive)), func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+		assert.Equal(t, []byte("world"), root.Find("test/hello.txt").GetData())
+	})
+
+	t.Run("Wrong forced compression", func(t *testing.T) {
+		_, err := Decompress(bytes.NewReader(archive), Gzip)
+		assert.ErrorContains(t, err, "on reading gzip stream")
+	})
+
+	t.Run("Compression from name", func(t *testing.T) {
+		assert.Equal(t, Gzip, CompressionFromName("out.tar.gz"))
+		assert.Equal(t, Gzip, CompressionFromName("out.TGZ"))
+		assert.Equal(t, Zstd, CompressionFromName("out.tar.zst"))
+		assert.Equal(t, None, CompressionFromName("out.tar"))
+		assert.Equal(t, Bzip2, CompressionFromName("out.tbz"))
+		_, err := Compress(&bytes.Buffer{}, Bzip2)
+		assert.ErrorContains(t, err, "not supported")
+		assert.NotNil(t, CheckWritable(Bzip2))
+		assert.NotNil(t, CheckWritable(Auto))
+		assert.Nil(t, CheckWritable(None))
+	})
+
+	t.Run("Parse compression", func(t *testing.T) {
+		c, err := ParseCompression("zstd")
+		require.Nil(t, err)
+		assert.Equal(t, Zstd, c)
+		_, err = ParseCompression("rar")
+		assert.ErrorContains(t, err, "unknown compression")
+	})
+}
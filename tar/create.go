@@ -0,0 +1,154 @@
+package tar
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// creator walk the filesystem to write entries into a tar archive
+type creator struct {
+	tw          *tar.Writer
+	excludes    []string
+	compression Compression
+	skip        []os.FileInfo // files never added to the archive (eg: the archive itself)
+}
+
+// CreateOption configure how the archive is created
+type CreateOption func(*creator)
+
+// WithExcludes skip files and directories matching one of the patterns (see filepath.Match),
+// a pattern is matched against the base name and the path of the file in the archive.
+func WithExcludes(patterns ...string) CreateOption {
+	return func(c *creator) { c.excludes = append(c.excludes, patterns...) }
+}
+
+// WithCompression compress the archive with given compression
+func WithCompression(compression Compression) CreateOption {
+	return func(c *creator) { c.compression = compression }
+}
+
+// WithSkipFiles never add given files to the archive, useful to not archive the output file itself
+func WithSkipFiles(files ...os.FileInfo) CreateOption {
+	return func(c *creator) { c.skip = append(c.skip, files...) }
+}
+
+// isExcluded return true if file must not be added to the archive
+func (c *creator) isExcluded(name string, fi os.FileInfo) (bool, error) {
+	for _, s := range c.skip {
+		if os.SameFile(s, fi) {
+			return true, nil
+		}
+	}
+	for _, pattern := range c.excludes {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		for _, candidate := range []string{filepath.Base(name), strings.TrimSuffix(name, "/")} {
+			match, err := filepath.Match(pattern, candidate)
+			if err != nil {
+				return false, fmt.Errorf("error on exclude pattern %s: %s", pattern, err)
+			}
+			if match {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// archiveName return the name of a file in the archive, leading "/" and parent directories are removed like tar does
+func archiveName(path string) string {
+	name := filepath.ToSlash(filepath.Clean(path))
+	for strings.HasPrefix(name, "../") {
+		name = name[3:]
+	}
+	return strings.TrimPrefix(name, "/")
+}
+
+// addFile write header and content of file at path into the archive
+func (c *creator) addFile(path string, fi os.FileInfo) error {
+	name := archiveName(path)
+	if name == "" || name == ".." {
+		name = "."
+	}
+	var link string
+	if fi.Mode()&fs.ModeSymlink != 0 {
+		var err error
+		if link, err = os.Readlink(path); err != nil {
+			return fmt.Errorf("error on read symlink %s: %s", path, err)
+		}
+	}
+	header, err := tar.FileInfoHeader(fi, link)
+	if err != nil {
+		return fmt.Errorf("error on create header for %s: %s", path, err)
+	}
+	header.Name = name
+	if fi.IsDir() && !strings.HasSuffix(name, "/") {
+		header.Name += "/"
+	}
+	if err := c.tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("error on write header for %s: %s", path, err)
+	}
+	if !fi.Mode().IsRegular() {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error on open file %s: %s", path, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(c.tw, f); err != nil {
+		return fmt.Errorf("error on write file %s: %s", path, err)
+	}
+	return nil
+}
+
+// add walk through path and add all its files to the archive
+func (c *creator) add(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("error on stat %s: %s", path, err)
+		}
+		excluded, err := c.isExcluded(archiveName(path), fi)
+		if err != nil {
+			return err
+		}
+		if excluded && d.IsDir() {
+			return filepath.SkipDir
+		}
+		if excluded {
+			return nil
+		}
+		return c.addFile(path, fi)
+	})
+}
+
+// Create write a tar archive of given files and directories (walked recursively) to w.
+// Modes, modification times, ownership and symlinks are recorded in the archive.
+func Create(w io.Writer, paths []string, opts ...CreateOption) error {
+	c := &creator{compression: None}
+	for _, opt := range opts {
+		opt(c)
+	}
+	cw, err := Compress(w, c.compression)
+	if err != nil {
+		return err
+	}
+	c.tw = tar.NewWriter(cw)
+	for _, path := range paths {
+		if err := c.add(path); err != nil {
+			return fmt.Errorf("error on add %s to archive: %s", path, err)
+		}
+	}
+	if err := c.tw.Close(); err != nil {
+		return fmt.Errorf("error on close archive: %s", err)
+	}
+	return cw.Close()
+}
@@ -0,0 +1,72 @@
+package tar
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreate(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	mtime := time.Date(2021, time.June, 2, 10, 0, 0, 0, time.UTC)
+	require.Nil(t, os.MkdirAll(filepath.Join(src, "nested", "build"), 0755))
+	require.Nil(t, os.WriteFile(filepath.Join(src, "readme.txt"), []byte("hello"), 0640))
+	require.Nil(t, os.WriteFile(filepath.Join(src, "nested", "app.log"), []byte("log"), 0600))
+	require.Nil(t, os.WriteFile(filepath.Join(src, "nested", "build", "out.o"), []byte("bin"), 0600))
+	require.Nil(t, os.Chtimes(filepath.Join(src, "readme.txt"), mtime, mtime))
+	require.Nil(t, os.Symlink("readme.txt", filepath.Join(src, "link")))
+
+	for _, c := range []Compression{None, Gzip, Zstd} {
+		t.Run("Create with compression "+string(c), func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Create(&buf, []string{src}, WithCompression(c), WithExcludes("*.log", archiveName(filepath.Join(src, "nested", "build"))))
+			require.Nil(t, err)
+			if c != None {
+				assert.Equal(t, c, DetectCompression(buf.Bytes()))
+			}
+
+			root, err := Scan(&buf, func(n *SimpleNode) error { return nil })
+			require.Nil(t, err)
+			paths := map[string]*SimpleNode{}
+			require.Nil(t, root.OnNestedChildren(func(n *SimpleNode) error {
+				if !n.IsImplicit() { // Parents of src are not in the archive
+					paths[n.GetPath()] = n
+				}
+				return nil
+			}))
+			base := "/" + archiveName(src)
+			assert.Len(t, paths, 4) // src, readme.txt, link, nested
+			require.Contains(t, paths, base+"/readme.txt")
+			readme := paths[base+"/readme.txt"]
+			assert.Equal(t, "hello", string(readme.GetData()))
+			assert.Equal(t, os.FileMode(0640), readme.Mode().Perm())
+			assert.Equal(t, mtime, readme.ModTime().UTC())
+			require.Contains(t, paths, base+"/link")
+			assert.Equal(t, "readme.txt", paths[base+"/link"].GetHeader().Linkname)
+			assert.True(t, paths[base+"/nested"].IsDir())
+		})
+	}
+
+	t.Run("Skip output archive", func(t *testing.T) {
+		out, err := os.Create(filepath.Join(src, "out.tar"))
+		require.Nil(t, err)
+		defer os.Remove(out.Name())
+		fi, err := out.Stat()
+		require.Nil(t, err)
+		require.Nil(t, Create(out, []string{src}, WithSkipFiles(fi)))
+		require.Nil(t, out.Close())
+
+		f, err := os.Open(out.Name())
+		require.Nil(t, err)
+		defer f.Close()
+		list, err := List(f)
+		require.Nil(t, err)
+		assert.NotContains(t, list, "/"+archiveName(out.Name()))
+	})
+}
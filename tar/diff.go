@@ -0,0 +1,251 @@
+package tar
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeType is the kind of difference of an entry between two archives
+type ChangeType string
+
+const (
+	Added    ChangeType = "added"
+	Removed  ChangeType = "removed"
+	Modified ChangeType = "modified"
+)
+
+// Change is an entry which differs between two archives
+type Change[T any] struct {
+	Path    string // Path of the entry relative to compared roots
+	Type    ChangeType
+	Reasons []string // What differs on a modified entry: type, size, mode, owner, linkname or content
+	Old     *Node[T] // Entry in the first archive, nil if added
+	New     *Node[T] // Entry in the second archive, nil if removed
+}
+
+// relativeNodes index all nested children of root by their path relative to root
+func relativeNodes[T any](root *Node[T]) map[string]*Node[T] {
+	nodes := map[string]*Node[T]{}
+	_ = root.OnNestedChildren(func(nd *Node[T]) error {
+		nodes["/"+strings.TrimPrefix(strings.TrimPrefix(nd.GetPath(), root.GetPath()), "/")] = nd
+		return nil
+	})
+	return nodes
+}
+
+// entryType return the type flag of a node, regular file flags are merged
+func entryType[T any](n *Node[T]) byte {
+	if n.header.Typeflag == tar.TypeRegA {
+		return tar.TypeReg
+	}
+	return n.header.Typeflag
+}
+
+// compareNodes return what differs between two entries of the same path.
+// Metadata of implicit directories are not compared as they are not stored in the archive.
+func compareNodes[T any](a, b *Node[T]) ([]string, error) {
+	if entryType(a) != entryType(b) {
+		return []string{"type"}, nil
+	}
+	if a.IsImplicit() || b.IsImplicit() {
+		return nil, nil
+	}
+	var reasons []string
+	ha, hb := a.header, b.header
+	if a.IsRegular() && ha.Size != hb.Size {
+		reasons = append(reasons, "size")
+	}
+	if ha.Mode&07777 != hb.Mode&07777 {
+		reasons = append(reasons, "mode")
+	}
+	if ha.Uid != hb.Uid || ha.Gid != hb.Gid || ha.Uname != hb.Uname || ha.Gname != hb.Gname {
+		reasons = append(reasons, "owner")
+	}
+	if ha.Linkname != hb.Linkname {
+		reasons = append(reasons, "linkname")
+	}
+	if a.IsRegular() && ha.Size == hb.Size {
+		sa, err := a.Hash()
+		if err != nil {
+			return nil, fmt.Errorf("error on read %s: %s", a.GetPath(), err)
+		}
+		sb, err := b.Hash()
+		if err != nil {
+			return nil, fmt.Errorf("error on read %s: %s", b.GetPath(), err)
+		}
+		if !bytes.Equal(sa, sb) {
+			reasons = append(reasons, "content")
+		}
+	}
+	return reasons, nil
+}
+
+// Diff compare two scanned trees and return added, removed and modified entries sorted by path.
+// Entries are modified if their type, size, mode, owner, link target or content (sha256 hash) differ.
+func Diff[T any](a, b *Node[T]) ([]Change[T], error) {
+	oldNodes, newNodes := relativeNodes(a), relativeNodes(b)
+	paths := make([]string, 0, len(oldNodes)+len(newNodes))
+	for p := range oldNodes {
+		paths = append(paths, p)
+	}
+	for p := range newNodes {
+		if _, ok := oldNodes[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []Change[T]
+	for _, p := range paths {
+		o, n := oldNodes[p], newNodes[p]
+		switch {
+		case o == nil:
+			changes = append(changes, Change[T]{Path: p, Type: Added, New: n})
+		case n == nil:
+			changes = append(changes, Change[T]{Path: p, Type: Removed, Old: o})
+		default:
+			reasons, err := compareNodes(o, n)
+			if err != nil {
+				return nil, err
+			}
+			if len(reasons) > 0 {
+				changes = append(changes, Change[T]{Path: p, Type: Modified, Reasons: reasons, Old: o, New: n})
+			}
+		}
+	}
+	return changes, nil
+}
+
+const binarySniffLen = 8000 // Same length as git to look for a NUL byte
+
+// IsBinary return true if data looks like binary content, it contains a NUL byte in its first 8000 bytes like git and file check
+func IsBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), binarySniffLen)], 0) >= 0
+}
+
+// maxDiffCells limit the size of the lines matrix used to compute a diff, bigger changes are shown as a whole replacement
+const maxDiffCells = 1 << 22
+
+// diffOp is a line of a diff: ' ' kept, '-' removed or '+' added
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// splitLines return lines of data without their line feed
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// diffLines return the edit script from a to b with a longest common subsequence of lines
+func diffLines(a, b []string) []diffOp {
+	var prefix, suffix []diffOp
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		prefix = append(prefix, diffOp{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		suffix = append([]diffOp{{' ', a[len(a)-1]}}, suffix...)
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	ops := prefix
+	if len(a)*len(b) > maxDiffCells {
+		for _, l := range a {
+			ops = append(ops, diffOp{'-', l})
+		}
+		for _, l := range b {
+			ops = append(ops, diffOp{'+', l})
+		}
+		return append(ops, suffix...)
+	}
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i, j = i+1, j+1
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return append(ops, suffix...)
+}
+
+// hunkRange format a range of lines in a hunk header, start is the 0 based index of the first line
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// UnifiedDiff return the differences between a and b in unified format with context lines around changes,
+// an empty string if they are equal.
+func UnifiedDiff(oldName, newName string, a, b []byte, context int) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+	// Position of each operation in a and b
+	aPos, bPos := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	var changes []int
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.kind != '+' {
+			aPos[i+1]++
+		}
+		if op.kind != '-' {
+			bPos[i+1]++
+		}
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for c := 0; c < len(changes); {
+		start := max(0, changes[c]-context)
+		last := c
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*context {
+			last++
+		}
+		end := min(len(ops), changes[last]+context+1)
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aPos[start], aPos[end]-aPos[start]), hunkRange(bPos[start], bPos[end]-bPos[start]))
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			sb.WriteByte('\n')
+		}
+		c = last + 1
+	}
+	return sb.String()
+}
@@ -0,0 +1,89 @@
+package tar
+
+import (
+	"archive/tar"
+	"testing"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	oldFiles := []test.File{
+		{Name: "./app/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "./app/same.txt", Mode: 0644, Body: "same"},
+		{Name: "./app/content.txt", Mode: 0644, Body: "old"},
+		{Name: "./app/size.txt", Mode: 0644, Body: "small"},
+		{Name: "./app/mode.sh", Mode: 0644, Body: "echo"},
+		{Name: "./app/link", Mode: 0777, Type: tar.TypeSymlink, Linkname: "same.txt"},
+		{Name: "./app/removed.txt", Mode: 0644, Body: "removed"},
+		{Name: "./app/type", Mode: 0644, Body: ""},
+	}
+	newFiles := []test.File{
+		{Name: "./app/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "./app/same.txt", Mode: 0644, Body: "same"},
+		{Name: "./app/content.txt", Mode: 0644, Body: "new"},
+		{Name: "./app/size.txt", Mode: 0644, Body: "bigger"},
+		{Name: "./app/mode.sh", Mode: 0755, Body: "echo"},
+		{Name: "./app/link", Mode: 0777, Type: tar.TypeSymlink, Linkname: "content.txt"},
+		{Name: "./app/added.txt", Mode: 0644, Body: "added"},
+		{Name: "./app/type/", Mode: 0755, Type: tar.TypeDir},
+	}
+	a, err := Scan(test.CreateArchive(t, oldFiles), func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+	b, err := Scan(test.CreateArchive(t, newFiles), func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+
+	changes, err := Diff(a, b)
+	require.Nil(t, err)
+	type result struct {
+		Path    string
+		Type    ChangeType
+		Reasons []string
+	}
+	var results []result
+	for _, c := range changes {
+		results = append(results, result{Path: c.Path, Type: c.Type, Reasons: c.Reasons})
+	}
+	assert.Equal(t, []result{
+		{Path: "/app/added.txt", Type: Added},
+		{Path: "/app/content.txt", Type: Modified, Reasons: []string{"content"}},
+		{Path: "/app/link", Type: Modified, Reasons: []string{"linkname"}},
+		{Path: "/app/mode.sh", Type: Modified, Reasons: []string{"mode"}},
+		{Path: "/app/removed.txt", Type: Removed},
+		{Path: "/app/size.txt", Type: Modified, Reasons: []string{"size"}},
+		{Path: "/app/type", Type: Modified, Reasons: []string{"type"}},
+	}, results)
+
+	t.Run("Same archive", func(t *testing.T) {
+		changes, err := Diff(a, a)
+		require.Nil(t, err)
+		assert.Empty(t, changes)
+	})
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	oldData := []byte("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n")
+	newData := []byte("a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n")
+	expected := `--- a/file
++++ b/file
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+	assert.Equal(t, expected, UnifiedDiff("a/file", "b/file", oldData, newData, 3))
+	assert.Equal(t, "", UnifiedDiff("a/file", "b/file", oldData, oldData, 3))
+	assert.Equal(t, "--- /dev/null\n+++ b/file\n@@ -0,0 +1,2 @@\n+x\n+y\n", UnifiedDiff("/dev/null", "b/file", nil, []byte("x\ny\n"), 3))
+	assert.True(t, IsBinary([]byte{'E', 'L', 'F', 0}))
+	assert.False(t, IsBinary([]byte("text")))
+}
@@ -0,0 +1,459 @@
+package tar
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const ExtractFolder = "guntar_extracted"
+
+// EntryError is an error on extracting a single entry of the archive
+type EntryError struct {
+	Path string
+	Err  error
+}
+
+func (e EntryError) Error() string { return fmt.Sprintf("%s: %s", e.Path, e.Err) }
+func (e EntryError) Unwrap() error { return e.Err }
+
+// ExtractError report all entries which could not be extracted
+type ExtractError []EntryError
+
+func (e ExtractError) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return fmt.Sprintf("failed to extract %d entries:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+func (e ExtractError) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// UnsafePathError is raised when an entry would be written outside of the extraction directory
+type UnsafePathError struct {
+	Name   string // Name of the entry in the archive
+	Reason string
+}
+
+func (e UnsafePathError) Error() string {
+	return fmt.Sprintf("unsafe path in entry %s: %s", e.Name, e.Reason)
+}
+
+// extractor write archive entries under outputPath and keep track of failed entries
+type extractor struct {
+	outputPath          string
+	realOutputPath      string // outputPath with symlinks resolved
+	errs                ExtractError
+	preservePermissions bool
+	preserveTimes       bool
+	sameOwner           bool
+	allowUnsafePaths    bool
+	jobs                int            // number of files written concurrently by Extract
+	dirs                []extractedDir // dirs metadata are set once all children are written
+	dirsMu              sync.Mutex     // dirsMu protect dirs from concurrent writers
+}
+
+type extractedDir struct {
+	path   string
+	target string
+	header *tar.Header
+}
+
+// ExtractOption configure how entries are written on disk
+type ExtractOption func(*extractor)
+
+// WithPreservePermissions apply the exact mode of archive entries (ignoring umask) to files and directories
+func WithPreservePermissions() ExtractOption {
+	return func(e *extractor) { e.preservePermissions = true }
+}
+
+// WithPreserveTimes restore modification time of files and directories
+func WithPreserveTimes() ExtractOption {
+	return func(e *extractor) { e.preserveTimes = true }
+}
+
+// WithSameOwner restore owner and group of entries, only applied when running as root
+func WithSameOwner() ExtractOption {
+	return func(e *extractor) { e.sameOwner = os.Geteuid() == 0 }
+}
+
+// WithUnsafePaths disable path checks: entry and link names are used as is (absolute and parent paths included),
+// symlinks can point anywhere and entries can be written through symlinks. Only use it with trusted archives.
+func WithUnsafePaths() ExtractOption {
+	return func(e *extractor) { e.allowUnsafePaths = true }
+}
+
+// WithJobs write regular files with n concurrent workers when extracting a scanned tree (at least 1).
+// Directories and symlinks are created before files and hardlinks after them, ExtractStream ignores it.
+func WithJobs(n int) ExtractOption {
+	return func(e *extractor) { e.jobs = max(n, 1) }
+}
+
+func newExtractor(outputPath string, opts ...ExtractOption) (*extractor, error) {
+	if len(outputPath) == 0 {
+		var err error
+		outputPath, err = os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("error on get current directory: %s", err)
+		}
+	}
+	outputPath = filepath.Join(outputPath, ExtractFolder)
+	if err := os.Mkdir(outputPath, 0777); os.IsExist(err) {
+		return nil, fmt.Errorf("error on create extract directory %s: %s", outputPath, err)
+	}
+	realOutputPath, err := filepath.EvalSymlinks(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("error on resolve extract directory %s: %s", outputPath, err)
+	}
+	e := &extractor{outputPath: outputPath, realOutputPath: realOutputPath, jobs: 1}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e, nil
+}
+
+// report keep err as a failed entry
+func (e *extractor) report(path string, err error) {
+	if err != nil {
+		e.errs = append(e.errs, EntryError{Path: path, Err: err})
+	}
+}
+
+// err set directories metadata and return all reported errors, nil if all entries are extracted
+func (e *extractor) err() error {
+	// Deepest directories first, writing metadata of a directory must not change its parent
+	sort.SliceStable(e.dirs, func(i, j int) bool {
+		return strings.Count(e.dirs[i].target, string(filepath.Separator)) > strings.Count(e.dirs[j].target, string(filepath.Separator))
+	})
+	for _, d := range e.dirs {
+		e.report(d.path, e.setMetadata(d.target, d.header))
+	}
+	e.dirs = nil
+	if len(e.errs) > 0 {
+		return e.errs
+	}
+	return nil
+}
+
+// target return the path on disk of an archive path.
+// Archive path is sanitized to stay in the output directory, unless unsafe paths are allowed
+func (e *extractor) target(path string) string {
+	if e.allowUnsafePaths && filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	if e.allowUnsafePaths {
+		return filepath.Join(e.outputPath, path)
+	}
+	return filepath.Join(e.outputPath, filepath.Join("/", path))
+}
+
+// isWithin return true if p is root or one of its children
+func isWithin(root, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// checkParent raise an error if parent directory of target resolves outside of the output directory through symlinks
+func (e *extractor) checkParent(name, target string) error {
+	dir, err := realDir(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+	if !isWithin(e.realOutputPath, dir) {
+		return UnsafePathError{Name: name, Reason: fmt.Sprintf("%s is written through a symlink outside of output directory", target)}
+	}
+	return nil
+}
+
+// realDir return the path of dir with symlinks resolved, missing directories are resolved as they will be created
+func realDir(dir string) (string, error) {
+	existing := dir
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", fmt.Errorf("error on resolve directory %s: %s", existing, err)
+	}
+	rel, err := filepath.Rel(existing, dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(real, rel), nil
+}
+
+// checkSymlink raise an error if relative symlink written at target can resolve outside of the output directory.
+// Link target is resolved against entries already extracted: going up (..) is only allowed through real directories,
+// as a symlink or a missing entry could be replaced later in the archive to point elsewhere.
+// Other symlinks are checked when they are written, so descending through them stays in the output directory.
+// Absolute symlinks (eg: usr/bin/python -> /usr/bin/python3 in a root filesystem) are created as is,
+// writes through them are rejected by checkParent when they resolve outside of the output directory.
+func (e *extractor) checkSymlink(name, target, linkname string) error {
+	if filepath.IsAbs(linkname) {
+		return nil
+	}
+	current, err := realDir(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+	resolved := true // resolved is false once a component is a symlink or missing on disk
+	for _, c := range strings.Split(linkname, "/") {
+		switch {
+		case c == "" || c == ".":
+			continue
+		case c == ".." && !resolved:
+			return UnsafePathError{Name: name, Reason: fmt.Sprintf("symlink %s goes up through a symlink or a missing directory", linkname)}
+		case c == "..":
+			current = filepath.Dir(current)
+			continue
+		}
+		current = filepath.Join(current, c)
+		if !resolved {
+			continue
+		}
+		if fi, err := os.Lstat(current); err != nil || fi.Mode()&fs.ModeSymlink != 0 {
+			resolved = false
+		}
+	}
+	if !isWithin(e.realOutputPath, current) {
+		return UnsafePathError{Name: name, Reason: fmt.Sprintf("symlink %s points outside of output directory", linkname)}
+	}
+	return nil
+}
+
+// forgetDirs drop metadata of extracted directories at or under target, once they are removed
+func (e *extractor) forgetDirs(target string) {
+	e.dirsMu.Lock()
+	defer e.dirsMu.Unlock()
+	dirs := e.dirs[:0]
+	for _, d := range e.dirs {
+		if !isWithin(target, d.target) {
+			dirs = append(dirs, d)
+		}
+	}
+	e.dirs = dirs
+}
+
+// mkParent create parent directories of an entry
+func (e *extractor) mkParent(target string) error {
+	dirPath := filepath.Dir(target)
+	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
+		err := os.MkdirAll(dirPath, 0777) // Permissions are set later if directory is in the archive
+		if err != nil {
+			return fmt.Errorf("error on create directory %s: %s", dirPath, err)
+		}
+	}
+	return nil
+}
+
+// extractEntry create the entry described by header at path, open is used to read regular file content
+func (e *extractor) extractEntry(path string, header *tar.Header, open func() (io.ReadCloser, error)) error {
+	target := e.target(path)
+	linkTarget := e.target(header.Linkname)
+	if e.allowUnsafePaths {
+		target = e.target(header.Name)
+	} else {
+		if err := e.checkParent(header.Name, target); err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeSymlink:
+			if err := e.checkSymlink(header.Name, target, header.Linkname); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			if err := e.checkParent(header.Name, linkTarget); err != nil {
+				return err
+			}
+			// A hardlink to a symlink is a copy of the symlink, its target is resolved from the new location
+			if linkname, err := os.Readlink(linkTarget); err == nil {
+				if err := e.checkSymlink(header.Name, target, linkname); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := e.mkParent(target); err != nil {
+		return err
+	}
+	if fi, err := os.Lstat(target); err == nil && (!fi.IsDir() || header.Typeflag != tar.TypeDir) {
+		// Replace existing entry like tar does, it avoids writing through symlinks.
+		// A directory replaced by another type of entry loses its children, like when the archive is scanned.
+		if err := os.RemoveAll(target); err != nil {
+			return fmt.Errorf("error on remove existing entry %s: %s", target, err)
+		}
+		if fi.IsDir() {
+			e.forgetDirs(target)
+		}
+	}
+	switch header.Typeflag {
+	case tar.TypeDir:
+		if err := os.MkdirAll(target, 0777); err != nil {
+			return fmt.Errorf("error on create directory %s: %s", target, err)
+		}
+		e.dirsMu.Lock()
+		e.dirs = append(e.dirs, extractedDir{path: path, target: target, header: header})
+		e.dirsMu.Unlock()
+		return nil
+	case tar.TypeSymlink:
+		if err := os.Symlink(header.Linkname, target); err != nil {
+			return fmt.Errorf("error on create symlink %s: %s", target, err)
+		}
+		return e.setOwner(target, header)
+	case tar.TypeLink:
+		if err := os.Link(linkTarget, target); err != nil {
+			return fmt.Errorf("error on create hardlink %s: %s", target, err)
+		}
+		return nil // Hardlink share metadata with its target
+	case tar.TypeReg, tar.TypeRegA, tar.TypeGNUSparse:
+		if err := writeFile(target, header.FileInfo().Mode().Perm(), open); err != nil {
+			return fmt.Errorf("error on create file %s: %s", target, err)
+		}
+		return e.setMetadata(target, header)
+	}
+	return fmt.Errorf("unsupported entry type %q", header.Typeflag)
+}
+
+// setMetadata apply ownership, permissions and times of header to target depending on extractor options
+func (e *extractor) setMetadata(target string, header *tar.Header) error {
+	if err := e.setOwner(target, header); err != nil {
+		return err
+	}
+	if e.preservePermissions {
+		if err := os.Chmod(target, header.FileInfo().Mode()&(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky)); err != nil {
+			return fmt.Errorf("error on set permissions of %s: %s", target, err)
+		}
+	}
+	if e.preserveTimes {
+		atime := header.AccessTime
+		if atime.IsZero() {
+			atime = header.ModTime
+		}
+		if err := os.Chtimes(target, atime, header.ModTime); err != nil {
+			return fmt.Errorf("error on set times of %s: %s", target, err)
+		}
+	}
+	return nil
+}
+
+// setOwner change owner of target (without following symlinks), user and group names are preferred over ids
+func (e *extractor) setOwner(target string, header *tar.Header) error {
+	if !e.sameOwner {
+		return nil
+	}
+	uid, gid := header.Uid, header.Gid
+	if u, err := user.Lookup(header.Uname); header.Uname != "" && err == nil {
+		if id, err := strconv.Atoi(u.Uid); err == nil {
+			uid = id
+		}
+	}
+	if g, err := user.LookupGroup(header.Gname); header.Gname != "" && err == nil {
+		if id, err := strconv.Atoi(g.Gid); err == nil {
+			gid = id
+		}
+	}
+	if err := os.Lchown(target, uid, gid); err != nil {
+		return fmt.Errorf("error on set owner of %s: %s", target, err)
+	}
+	return nil
+}
+
+// Extract all nodes to the output file.
+// isSkipped callback can be used to add logic (skip current node if true) on nodes extraction.
+// Entries which can't be created don't stop the extraction, they are reported in an ExtractError.
+// Directories and symlinks are created first, then regular files (concurrently with WithJobs) and hardlinks last.
+// Options can be used to restore permissions, times and ownership from the archive.
+func Extract[T any](node *Node[T], outputPath string, isSkipped func(*Node[T]) bool, opts ...ExtractOption) error {
+	e, err := newExtractor(outputPath, opts...)
+	if err != nil {
+		return err
+	}
+	var files []*Node[T]
+	var links []*Node[T] // Hardlinks are created once all targets are extracted
+	err = node.OnNestedChildren(func(nd *Node[T]) error {
+		if nd.IsImplicit() || isSkipped(nd) { // Implicit directories are created with their children
+			return nil
+		}
+		switch nd.header.Typeflag {
+		case tar.TypeLink:
+			links = append(links, nd)
+		case tar.TypeReg, tar.TypeRegA, tar.TypeGNUSparse:
+			files = append(files, nd)
+		default:
+			e.report(nd.GetPath(), e.extractEntry(nd.GetPath(), nd.header, nd.Open))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	extractFiles(e, files)
+	for _, nd := range links {
+		e.report(nd.GetPath(), e.extractEntry(nd.GetPath(), nd.header, nd.Open))
+	}
+	return e.err()
+}
+
+// extractFiles write regular files with e.jobs workers, errors are reported in files order
+func extractFiles[T any](e *extractor, files []*Node[T]) {
+	errs := make([]error, len(files))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(e.jobs, len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = e.extractEntry(files[i].GetPath(), files[i].header, files[i].Open)
+			}
+		}()
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	for i, nd := range files {
+		e.report(nd.GetPath(), errs[i])
+	}
+}
+
+// writeFile copy content into a new file
+func writeFile(filePath string, perm os.FileMode, open func() (io.ReadCloser, error)) error {
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,297 @@
+package tar
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractEntryTypes(t *testing.T) {
+	files := []test.File{
+		{Name: "./empty/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "./b/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "./b/target.txt", Mode: 0644, Body: "target"},
+		{Name: "./a/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "./a/symlink", Mode: 0777, Type: tar.TypeSymlink, Linkname: "../b/target.txt"},
+		{Name: "./a/hardlink", Mode: 0644, Type: tar.TypeLink, Linkname: "./b/target.txt"},
+		{Name: "./fifo", Mode: 0644, Type: tar.TypeFifo},
+	}
+	root, err := Scan(test.CreateArchive(t, files), func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+	tmpDir := t.TempDir()
+
+	err = Extract(root, tmpDir, func(n *SimpleNode) bool { return false })
+	var extractErr ExtractError
+	require.True(t, errors.As(err, &extractErr))
+	require.Len(t, extractErr, 1)
+	assert.Equal(t, "/fifo", extractErr[0].Path)
+	assert.ErrorContains(t, err, "unsupported entry type")
+
+	t.Run("Empty directory", func(t *testing.T) {
+		assert.DirExists(t, getExtractedPath(tmpDir, "empty"))
+	})
+
+	t.Run("Symlink", func(t *testing.T) {
+		link, err := os.Readlink(getExtractedPath(tmpDir, "a/symlink"))
+		require.Nil(t, err)
+		assert.Equal(t, "../b/target.txt", link)
+		data, err := os.ReadFile(getExtractedPath(tmpDir, "a/symlink"))
+		require.Nil(t, err)
+		assert.Equal(t, "target", string(data))
+	})
+
+	t.Run("Hardlink", func(t *testing.T) {
+		link, err := os.Stat(getExtractedPath(tmpDir, "a/hardlink"))
+		require.Nil(t, err)
+		target, err := os.Stat(getExtractedPath(tmpDir, "b/target.txt"))
+		require.Nil(t, err)
+		assert.True(t, os.SameFile(link, target))
+	})
+
+	t.Run("Hardlink without extracted target", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		err := Extract(root, tmpDir, func(n *SimpleNode) bool { return n.GetPath() != "/a/hardlink" })
+		assert.ErrorContains(t, err, "error on create hardlink")
+	})
+}
+
+func TestExtractMetadata(t *testing.T) {
+	mtime := time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC)
+	files := []test.File{
+		{Name: "./ro/", Mode: 0555, Type: tar.TypeDir, ModTime: mtime},
+		{Name: "./ro/file.txt", Mode: 0640, Body: "read only", ModTime: mtime},
+	}
+	root, err := Scan(test.CreateArchive(t, files), func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+
+	t.Run("Default metadata", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.Nil(t, Extract(root, tmpDir, func(n *SimpleNode) bool { return false }))
+		fi, err := os.Stat(getExtractedPath(tmpDir, "ro"))
+		require.Nil(t, err)
+		assert.NotEqual(t, fs.FileMode(0555), fi.Mode().Perm())
+		assert.NotEqual(t, mtime, fi.ModTime().UTC())
+	})
+
+	t.Run("Preserve permissions and times", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		err := Extract(root, tmpDir, func(n *SimpleNode) bool { return false }, WithPreservePermissions(), WithPreserveTimes())
+		require.Nil(t, err)
+		defer os.Chmod(getExtractedPath(tmpDir, "ro"), 0755) // Let TempDir cleanup remove files
+
+		dir, err := os.Stat(getExtractedPath(tmpDir, "ro"))
+		require.Nil(t, err)
+		assert.Equal(t, fs.FileMode(0555), dir.Mode().Perm())
+		assert.Equal(t, mtime, dir.ModTime().UTC())
+
+		file, err := os.Stat(getExtractedPath(tmpDir, "ro/file.txt"))
+		require.Nil(t, err)
+		assert.Equal(t, fs.FileMode(0640), file.Mode().Perm())
+		assert.Equal(t, mtime, file.ModTime().UTC())
+	})
+}
+
+func TestExtractJobs(t *testing.T) {
+	mtime := time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC)
+	var files []test.File
+	for d := 0; d < 4; d++ {
+		files = append(files, test.File{Name: fmt.Sprintf("./dir%d/", d), Mode: 0555, Type: tar.TypeDir, ModTime: mtime})
+		for f := 0; f < 50; f++ {
+			name := fmt.Sprintf("./dir%d/file%d.txt", d, f)
+			files = append(files, test.File{Name: name, Mode: 0644, Body: name, ModTime: mtime})
+		}
+		files = append(files, test.File{Name: fmt.Sprintf("./dir%d/fifo", d), Mode: 0644, Type: tar.TypeFifo})
+	}
+	files = append(files, test.File{Name: "./hardlink", Mode: 0644, Type: tar.TypeLink, Linkname: "./dir3/file49.txt"})
+	root, err := Scan(test.CreateArchive(t, files), func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+
+	extract := func(t *testing.T, jobs int) ExtractError {
+		tmpDir := t.TempDir()
+		err := Extract(root, tmpDir, func(n *SimpleNode) bool { return false }, WithJobs(jobs), WithPreservePermissions(), WithPreserveTimes())
+		for d := 0; d < 4; d++ {
+			defer os.Chmod(getExtractedPath(tmpDir, fmt.Sprintf("dir%d", d)), 0755) // Let TempDir cleanup remove files
+		}
+		var extractErr ExtractError
+		require.True(t, errors.As(err, &extractErr))
+		for d := 0; d < 4; d++ {
+			dir, err := os.Stat(getExtractedPath(tmpDir, fmt.Sprintf("dir%d", d)))
+			require.Nil(t, err)
+			assert.Equal(t, fs.FileMode(0555), dir.Mode().Perm())
+			assert.Equal(t, mtime, dir.ModTime().UTC())
+			for f := 0; f < 50; f++ {
+				name := fmt.Sprintf("dir%d/file%d.txt", d, f)
+				data, err := os.ReadFile(getExtractedPath(tmpDir, name))
+				require.Nil(t, err)
+				assert.Equal(t, "./"+name, string(data))
+			}
+		}
+		assert.FileExists(t, getExtractedPath(tmpDir, "hardlink"))
+		return extractErr
+	}
+
+	sequential := extract(t, 1)
+	for i := 0; i < 5; i++ {
+		parallel := extract(t, 8)
+		assert.Equal(t, sequential, parallel)
+	}
+	require.Len(t, sequential, 4)
+	for d, err := range sequential {
+		assert.Equal(t, fmt.Sprintf("/dir%d/fifo", d), err.Path)
+	}
+}
+
+func TestExtractUnsafePaths(t *testing.T) {
+	outside := t.TempDir()
+	files := []test.File{
+		{Name: "abs", Mode: 0777, Type: tar.TypeSymlink, Linkname: "/etc/passwd"},
+		{Name: "up", Mode: 0777, Type: tar.TypeSymlink, Linkname: "../../outside"},
+		{Name: "a/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "a/parent", Mode: 0777, Type: tar.TypeSymlink, Linkname: ".."},
+		{Name: "escape", Mode: 0777, Type: tar.TypeSymlink, Linkname: "a/parent/.."},
+		{Name: "escape/pwned.txt", Mode: 0644, Body: "pwned"},
+		{Name: "b/c/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "b/c/up", Mode: 0777, Type: tar.TypeSymlink, Linkname: "../.."},
+		{Name: "copy", Mode: 0777, Type: tar.TypeLink, Linkname: "b/c/up"},
+		{Name: "later", Mode: 0777, Type: tar.TypeSymlink, Linkname: "z/../.."},
+		{Name: "etc", Mode: 0777, Type: tar.TypeSymlink, Linkname: outside},
+		{Name: "etc/pwned.conf", Mode: 0644, Body: "pwned"},
+	}
+	root, err := Scan(test.CreateArchive(t, files), func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+
+	t.Run("Reject escaping entries", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		err := Extract(root, tmpDir, func(n *SimpleNode) bool { return false })
+		var extractErr ExtractError
+		require.True(t, errors.As(err, &extractErr))
+		failed := []string{}
+		for _, e := range extractErr {
+			failed = append(failed, e.Path)
+		}
+		// escape resolves outside through a/parent, copy is b/c/up moved at the root, z could become a symlink later,
+		// etc/pwned.conf is written through an absolute symlink
+		assert.ElementsMatch(t, []string{"/up", "/escape", "/copy", "/later", "/etc/pwned.conf"}, failed)
+
+		var unsafeErr UnsafePathError
+		require.True(t, errors.As(err, &unsafeErr))
+		assert.Equal(t, "up", unsafeErr.Name)
+		assert.NoFileExists(t, filepath.Join(tmpDir, "pwned.txt"))
+		assert.NoFileExists(t, filepath.Join(outside, "pwned.conf"))
+		// Absolute symlinks are created as is
+		link, err := os.Readlink(getExtractedPath(tmpDir, "abs"))
+		require.Nil(t, err)
+		assert.Equal(t, "/etc/passwd", link)
+		assert.FileExists(t, getExtractedPath(tmpDir, "escape/pwned.txt"), "parent is created as a directory")
+		// Symlink staying in output directory is kept
+		link, err = os.Readlink(getExtractedPath(tmpDir, "a/parent"))
+		require.Nil(t, err)
+		assert.Equal(t, "..", link)
+	})
+
+	t.Run("Allow unsafe paths", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		err := Extract(root, tmpDir, func(n *SimpleNode) bool { return false }, WithUnsafePaths())
+		require.Nil(t, err)
+		link, err := os.Readlink(getExtractedPath(tmpDir, "abs"))
+		require.Nil(t, err)
+		assert.Equal(t, "/etc/passwd", link)
+		assert.FileExists(t, filepath.Join(tmpDir, "pwned.txt"))
+	})
+}
+
+func TestExtractStream(t *testing.T) {
+	files := []test.File{
+		{Name: "./etc/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "./etc/nginx/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "./etc/nginx/nginx.conf", Mode: 0644, Body: "worker_processes 1;"},
+		{Name: "./etc/hosts", Mode: 0644, Body: "127.0.0.1 localhost"},
+		{Name: "./etc/nginx/default", Mode: 0644, Type: tar.TypeLink, Linkname: "./etc/nginx/nginx.conf"},
+		{Name: "./etc/hosts", Mode: 0644, Body: "::1 localhost"},
+	}
+
+	t.Run("Extract all entries", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		err := ExtractStream(test.CreateArchive(t, files), tmpDir, func(n *SimpleNode) bool { return false })
+		require.Nil(t, err)
+		data, err := os.ReadFile(getExtractedPath(tmpDir, "etc/nginx/default"))
+		require.Nil(t, err)
+		assert.Equal(t, files[2].Body, string(data))
+		// Last entry wins
+		data, err = os.ReadFile(getExtractedPath(tmpDir, "etc/hosts"))
+		require.Nil(t, err)
+		assert.Equal(t, files[5].Body, string(data))
+	})
+
+	t.Run("Extract selected entries", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		err := ExtractStream(test.CreateArchive(t, files), tmpDir, func(n *SimpleNode) bool {
+			ok, err := MatchAny([]string{"etc/nginx/*.conf"}, n.GetPath())
+			require.Nil(t, err)
+			return !ok
+		})
+		require.Nil(t, err)
+		assert.FileExists(t, getExtractedPath(tmpDir, "etc/nginx/nginx.conf"))
+		assert.NoFileExists(t, getExtractedPath(tmpDir, "etc/hosts"))
+	})
+
+	t.Run("Stop streaming", func(t *testing.T) {
+		var paths []string
+		err := Stream(test.CreateArchive(t, files), func(n *SimpleNode) error {
+			paths = append(paths, n.GetPath())
+			if n.GetPath() == "/etc/nginx/nginx.conf" {
+				data, err := n.ReadData()
+				require.Nil(t, err)
+				assert.Equal(t, files[2].Body, string(data))
+				return fs.SkipAll
+			}
+			return nil
+		})
+		require.Nil(t, err)
+		assert.Equal(t, []string{"/etc", "/etc/nginx", "/etc/nginx/nginx.conf"}, paths)
+	})
+}
+
+func TestExtractTypeChanges(t *testing.T) {
+	files := []test.File{
+		{Name: "a/", Mode: 0700, Type: tar.TypeDir},
+		{Name: "a/x", Mode: 0644, Body: "x"},
+		{Name: "a", Mode: 0640, Body: "file a"},
+		{Name: "b", Mode: 0644, Body: "file b"},
+		{Name: "b/", Mode: 0750, Type: tar.TypeDir},
+	}
+	root, err := Scan(test.CreateArchive(t, files), func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+	for name, extract := range map[string]func(string) error{
+		"stream": func(dir string) error {
+			return ExtractStream(test.CreateArchive(t, files), dir, func(n *SimpleNode) bool { return false }, WithPreservePermissions())
+		},
+		"tree": func(dir string) error {
+			return Extract(root, dir, func(n *SimpleNode) bool { return false }, WithPreservePermissions())
+		},
+	} {
+		t.Run("Last occurrence wins on "+name+" extraction", func(t *testing.T) {
+			tmpDir := t.TempDir()
+			require.Nil(t, extract(tmpDir))
+			data, err := os.ReadFile(getExtractedPath(tmpDir, "a"))
+			require.Nil(t, err)
+			assert.Equal(t, "file a", string(data))
+			fi, err := os.Stat(getExtractedPath(tmpDir, "a"))
+			require.Nil(t, err)
+			assert.Equal(t, fs.FileMode(0640), fi.Mode(), "metadata of the replaced directory are not applied")
+			fi, err = os.Stat(getExtractedPath(tmpDir, "b"))
+			require.Nil(t, err)
+			assert.True(t, fi.IsDir())
+			assert.Equal(t, fs.FileMode(0750), fi.Mode().Perm())
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package tar
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+const maxSymlinks = 40 // Same limit as Linux to resolve a path
+
+var errIsDir = errors.New("is a directory")
+
+// FS expose a scanned archive as a read only file system, so it can be used by fs.WalkDir, fs.Glob, http.FS, etc...
+// Names are slash separated paths relative to the root node, symlinks and hardlinks are followed inside the archive.
+type FS[T any] struct {
+	root *Node[T]
+}
+
+var _ interface {
+	fs.ReadDirFS
+	fs.StatFS
+	fs.ReadFileFS
+} = &FS[struct{}]{}
+
+// NewFS return a file system with given node as root
+func NewFS[T any](root *Node[T]) *FS[T] {
+	return &FS[T]{root: root}
+}
+
+// isLink return true if node content is in another node
+func isLink[T any](n *Node[T]) bool {
+	return n.header != nil && (n.header.Typeflag == tar.TypeSymlink || n.header.Typeflag == tar.TypeLink)
+}
+
+// linkTarget return the name of the node targeted by a link, relative to the fs root
+func (f *FS[T]) linkTarget(n *Node[T]) string {
+	target := n.header.Linkname
+	if n.header.Typeflag == tar.TypeSymlink && !path.IsAbs(target) {
+		target = path.Join(strings.TrimPrefix(n.GetParent().GetPath(), f.root.GetPath()), target)
+	}
+	return strings.TrimPrefix(path.Join("/", target), "/")
+}
+
+// lookup return the node at name, the last element is only resolved if follow is true
+func (f *FS[T]) lookup(op, name string, follow bool, hops int) (*Node[T], error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	current := f.root
+	if name == "." || name == "" {
+		return current, nil
+	}
+	elems := strings.Split(name, "/")
+	for i, elem := range elems {
+		child := current.Find(elem)
+		if child == nil || !current.IsDir() {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		if isLink(child) && (follow || i < len(elems)-1) {
+			if hops >= maxSymlinks {
+				return nil, &fs.PathError{Op: op, Path: name, Err: errors.New("too many links")}
+			}
+			var err error
+			if child, err = f.lookup(op, f.linkTarget(child), true, hops+1); err != nil {
+				return nil, err
+			}
+		}
+		current = child
+	}
+	return current, nil
+}
+
+// Open open the named file or directory
+func (f *FS[T]) Open(name string) (fs.File, error) {
+	nd, err := f.lookup("open", name, true, 0)
+	if err != nil {
+		return nil, err
+	}
+	info := namedInfo{FileInfo: nd, name: path.Base(name)}
+	if nd.IsDir() {
+		return &dir[T]{node: nd, info: info}, nil
+	}
+	return &file[T]{node: nd, info: info}, nil
+}
+
+// Stat return file info of the named file, following links
+func (f *FS[T]) Stat(name string) (fs.FileInfo, error) {
+	nd, err := f.lookup("stat", name, true, 0)
+	if err != nil {
+		return nil, err
+	}
+	return namedInfo{FileInfo: nd, name: path.Base(name)}, nil
+}
+
+// ReadDir return the entries of the named directory sorted by name
+func (f *FS[T]) ReadDir(name string) ([]fs.DirEntry, error) {
+	nd, err := f.lookup("readdir", name, true, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !nd.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return readDir(nd), nil
+}
+
+// ReadFile return the content of the named file
+func (f *FS[T]) ReadFile(name string) ([]byte, error) {
+	nd, err := f.lookup("readfile", name, true, 0)
+	if err != nil {
+		return nil, err
+	}
+	if nd.IsDir() {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: errIsDir}
+	}
+	rc, err := nd.Open() // Caller owns returned data, it must not share node data
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+// namedInfo is a FileInfo with the name used to open it (root is "." and symlinks keep their own name)
+type namedInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (i namedInfo) Name() string { return i.name }
+
+func readDir[T any](n *Node[T]) []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, n.LenChildren())
+	for _, child := range n.GetChildren() {
+		entries = append(entries, fs.FileInfoToDirEntry(child))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries
+}
+
+// file is an opened regular file of the archive
+type file[T any] struct {
+	node *Node[T]
+	info fs.FileInfo
+	r    io.ReadCloser
+}
+
+func (f *file[T]) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *file[T]) reader() (io.ReadCloser, error) {
+	if f.r == nil {
+		var err error
+		if f.r, err = f.node.Open(); err != nil {
+			return nil, &fs.PathError{Op: "read", Path: f.info.Name(), Err: err}
+		}
+	}
+	return f.r, nil
+}
+
+func (f *file[T]) Read(p []byte) (int, error) {
+	r, err := f.reader()
+	if err != nil {
+		return 0, err
+	}
+	return r.Read(p)
+}
+
+func (f *file[T]) Seek(offset int64, whence int) (int64, error) {
+	r, err := f.reader()
+	if err != nil {
+		return 0, err
+	}
+	if s, ok := r.(io.Seeker); ok {
+		return s.Seek(offset, whence)
+	}
+	return 0, &fs.PathError{Op: "seek", Path: f.info.Name(), Err: errors.ErrUnsupported}
+}
+
+func (f *file[T]) ReadAt(p []byte, off int64) (int, error) {
+	r, err := f.reader()
+	if err != nil {
+		return 0, err
+	}
+	if ra, ok := r.(io.ReaderAt); ok {
+		return ra.ReadAt(p, off)
+	}
+	return 0, &fs.PathError{Op: "read", Path: f.info.Name(), Err: errors.ErrUnsupported}
+}
+
+func (f *file[T]) Close() error {
+	if f.r == nil {
+		return nil
+	}
+	return f.r.Close()
+}
+
+// dir is an opened directory of the archive
+type dir[T any] struct {
+	node    *Node[T]
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir[T]) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dir[T]) Close() error               { return nil }
+
+func (d *dir[T]) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: errIsDir}
+}
+
+// ReadDir return the next n entries of the directory, all remaining entries if n <= 0
+func (d *dir[T]) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.entries == nil {
+		d.entries = readDir(d.node)
+	}
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(remaining))
+	d.offset += n
+	return remaining[:n], nil
+}
@@ -0,0 +1,92 @@
+package tar
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS(t *testing.T) {
+	files := []test.File{
+		{Name: "./test/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "./test/readme.txt", Mode: 0600, Body: "This archive contains some text files."},
+		{Name: "./test/hello.txt", Mode: 0600, Body: "world"},
+		{Name: "gopher.txt", Mode: 0600, Body: "Gopher names:\nGeorge\nGeoffrey\nGonzo"},
+		{Name: "implicit/dir/todo.txt", Mode: 0600, Body: "Get animal handling license."},
+	}
+	archive := test.CreateArchive(t, files).Bytes()
+
+	t.Run("Validate file system from stream", func(t *testing.T) {
+		root, err := Scan(bytes.NewBuffer(archive), func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+		require.Nil(t, fstest.TestFS(NewFS(root), "test/readme.txt", "test/hello.txt", "gopher.txt", "implicit/dir/todo.txt"))
+	})
+
+	t.Run("Validate file system from lazy archive", func(t *testing.T) {
+		root, err := Scan(bytes.NewReader(archive), func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+		require.Nil(t, fstest.TestFS(NewFS(root), "test/readme.txt", "test/hello.txt", "gopher.txt", "implicit/dir/todo.txt"))
+	})
+
+	t.Run("Use file system with fs functions", func(t *testing.T) {
+		root, err := Scan(bytes.NewReader(archive), func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+		fsys := NewFS(root)
+
+		matches, err := fs.Glob(fsys, "test/*.txt")
+		require.Nil(t, err)
+		assert.Equal(t, []string{"test/hello.txt", "test/readme.txt"}, matches)
+
+		data, err := fs.ReadFile(fsys, "gopher.txt")
+		require.Nil(t, err)
+		assert.Equal(t, files[3].Body, string(data))
+
+		_, err = fsys.Open("missing.txt")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = fsys.Open("/gopher.txt")
+		assert.ErrorIs(t, err, fs.ErrInvalid)
+		_, err = fsys.ReadFile("test")
+		assert.ErrorContains(t, err, "is a directory")
+	})
+
+	t.Run("Follow links", func(t *testing.T) {
+		links := append(files,
+			test.File{Name: "lib", Mode: 0777, Type: tar.TypeSymlink, Linkname: "test"},
+			test.File{Name: "test/abs", Mode: 0777, Type: tar.TypeSymlink, Linkname: "/gopher.txt"},
+			test.File{Name: "hard", Mode: 0600, Type: tar.TypeLink, Linkname: "./test/hello.txt"},
+			test.File{Name: "loop", Mode: 0777, Type: tar.TypeSymlink, Linkname: "loop"},
+		)
+		root, err := Scan(test.CreateArchive(t, links), func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+		fsys := NewFS(root)
+
+		data, err := fsys.ReadFile("lib/hello.txt")
+		require.Nil(t, err)
+		assert.Equal(t, "world", string(data))
+		data, err = fsys.ReadFile("lib/abs")
+		require.Nil(t, err)
+		assert.Equal(t, files[3].Body, string(data))
+
+		f, err := fsys.Open("hard")
+		require.Nil(t, err)
+		defer f.Close()
+		data, err = io.ReadAll(f)
+		require.Nil(t, err)
+		assert.Equal(t, "world", string(data))
+
+		info, err := fsys.Stat("lib")
+		require.Nil(t, err)
+		assert.True(t, info.IsDir())
+		assert.Equal(t, "lib", info.Name())
+
+		_, err = fsys.Open("loop")
+		assert.ErrorContains(t, err, "too many links")
+	})
+}
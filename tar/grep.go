@@ -0,0 +1,100 @@
+package tar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// GrepLine is a line of a file matching a pattern, or a context line around a match
+type GrepLine struct {
+	Number  int // Line number starting at 1
+	Text    string
+	Context bool // Context is true for lines around matches which don't match
+}
+
+// grepper search a pattern in files content
+type grepper struct {
+	re     *regexp.Regexp
+	before int
+	after  int
+}
+
+// GrepOption configure how files are searched
+type GrepOption func(*grepper)
+
+// WithContext add before lines preceding and after lines following each matching line
+func WithContext(before, after int) GrepOption {
+	return func(g *grepper) { g.before, g.after = max(before, 0), max(after, 0) }
+}
+
+// grepFile return matching lines of r with their context, nil if content is binary
+func (g *grepper) grepFile(r io.Reader) ([]GrepLine, error) {
+	br := bufio.NewReaderSize(r, binarySniffLen)
+	head, err := br.Peek(binarySniffLen)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if IsBinary(head) {
+		return nil, nil
+	}
+	var lines, previous []GrepLine
+	remaining := 0 // Number of context lines to add after last match
+	for number := 1; ; number++ {
+		text, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(text) == 0 && err == io.EOF {
+			return lines, nil
+		}
+		line := GrepLine{Number: number, Text: strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r")}
+		switch {
+		case g.re.MatchString(line.Text):
+			lines = append(append(lines, previous...), line)
+			previous, remaining = previous[:0], g.after
+		case remaining > 0:
+			line.Context = true
+			lines = append(lines, line)
+			remaining--
+		case g.before > 0:
+			line.Context = true
+			if len(previous) == g.before {
+				previous = previous[1:]
+			}
+			previous = append(previous, line)
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+	}
+}
+
+// Grep stream the archive and search re in each regular file which is not skipped, binary files are ignored.
+// cb is called with the matching lines of each file containing at least one match.
+func Grep(r io.Reader, re *regexp.Regexp, isSkipped func(*SimpleNode) bool, cb func(n *SimpleNode, lines []GrepLine) error, opts ...GrepOption) error {
+	g := &grepper{re: re}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return Stream(r, func(n *SimpleNode) error {
+		if !n.IsRegular() || isSkipped(n) {
+			return nil
+		}
+		rc, err := n.Open()
+		if err != nil {
+			return fmt.Errorf("error on read %s: %s", n.GetPath(), err)
+		}
+		defer rc.Close()
+		lines, err := g.grepFile(rc)
+		if err != nil {
+			return fmt.Errorf("error on read %s: %s", n.GetPath(), err)
+		}
+		if len(lines) == 0 {
+			return nil
+		}
+		return cb(n, lines)
+	})
+}
@@ -0,0 +1,56 @@
+package tar
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrep(t *testing.T) {
+	files := []test.File{
+		{Name: "./etc/db.conf", Mode: 0644, Body: "host=db\nport=5432\nmax_connections=100\nuser=app\npassword=secret\ntimeout=5\nmax_connections_per_user=10\n"},
+		{Name: "./etc/app.conf", Mode: 0644, Body: "workers=4"},
+		{Name: "./bin/app", Mode: 0755, Body: "\x7fELF\x00max_connections"},
+		{Name: "./etc/skipped.conf", Mode: 0644, Body: "max_connections=1"},
+	}
+	grep := func(t *testing.T, pattern string, opts ...GrepOption) map[string][]GrepLine {
+		res := map[string][]GrepLine{}
+		err := Grep(test.CreateArchive(t, files), regexp.MustCompile(pattern), func(n *SimpleNode) bool {
+			return n.GetPath() == "/etc/skipped.conf"
+		}, func(n *SimpleNode, lines []GrepLine) error {
+			res[n.GetPath()] = lines
+			return nil
+		}, opts...)
+		require.Nil(t, err)
+		return res
+	}
+
+	t.Run("Matching lines", func(t *testing.T) {
+		assert.Equal(t, map[string][]GrepLine{
+			"/etc/db.conf": {{Number: 3, Text: "max_connections=100"}, {Number: 7, Text: "max_connections_per_user=10"}},
+		}, grep(t, "max_connections"))
+	})
+
+	t.Run("Last line without line feed", func(t *testing.T) {
+		assert.Equal(t, map[string][]GrepLine{"/etc/app.conf": {{Number: 1, Text: "workers=4"}}}, grep(t, "^workers"))
+	})
+
+	t.Run("Context lines", func(t *testing.T) {
+		assert.Equal(t, map[string][]GrepLine{
+			"/etc/db.conf": {
+				{Number: 2, Text: "port=5432", Context: true},
+				{Number: 3, Text: "max_connections=100"},
+				{Number: 4, Text: "user=app", Context: true},
+				{Number: 6, Text: "timeout=5", Context: true},
+				{Number: 7, Text: "max_connections_per_user=10"},
+			},
+		}, grep(t, "max_connections", WithContext(1, 1)))
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		assert.Empty(t, grep(t, "not found"))
+	})
+}
@@ -0,0 +1,144 @@
+package tar
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	minChunkSize = 64 << 10 // Smallest part of the file fetched by a range request
+	maxChunkSize = 8 << 20  // Largest part of the file fetched by a range request on sequential reads
+)
+
+// IsURL return true if name is an HTTP(S) URL
+func IsURL(name string) bool {
+	return strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://")
+}
+
+// HTTPReader read a remote file at random positions with HTTP range requests, it implements io.ReaderAt and io.ReadSeeker
+// so archives read from it are scanned without downloading files content.
+// Fetched parts are cached and grow while the file is read sequentially, so small reads don't cost a request each.
+type HTTPReader struct {
+	client *http.Client
+	url    string
+	size   int64
+	offset int64 // offset of next Read
+
+	mu     sync.Mutex
+	buf    []byte // buf is the last fetched part of the file
+	bufOff int64
+	chunk  int64
+}
+
+// OpenURL open the file at url, a *HTTPReader is returned when the server accepts range requests,
+// the body of a GET request otherwise. Returned reader must be closed.
+func OpenURL(client *http.Client, url string) (io.ReadCloser, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Head(url)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK && resp.Header.Get("Accept-Ranges") == "bytes" && resp.ContentLength >= 0 {
+			return &HTTPReader{client: client, url: url, size: resp.ContentLength, chunk: minChunkSize}, nil
+		}
+	}
+
+	resp, err = client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("on requesting %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// Size return the size of the remote file
+func (h *HTTPReader) Size() int64 { return h.size }
+
+// fetch return the part of the file starting at off with length n
+func (h *HTTPReader) fetch(off, n int64) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+n-1))
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("on requesting range of %s: %s", h.url, resp.Status)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(resp.Body, data); err != nil {
+		return nil, fmt.Errorf("on reading range of %s: %s", h.url, err)
+	}
+	return data, nil
+}
+
+func (h *HTTPReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= h.size {
+		return 0, io.EOF
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	end := min(off+int64(len(p)), h.size)
+	if off < h.bufOff || end > h.bufOff+int64(len(h.buf)) {
+		if off == h.bufOff+int64(len(h.buf)) {
+			h.chunk = min(h.chunk*2, maxChunkSize) // Sequential read
+		} else {
+			h.chunk = minChunkSize
+		}
+		data, err := h.fetch(off, min(max(end-off, h.chunk), h.size-off))
+		if err != nil {
+			return 0, err
+		}
+		h.buf, h.bufOff = data, off
+	}
+	n := copy(p, h.buf[off-h.bufOff:end-h.bufOff])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (h *HTTPReader) Read(p []byte) (int, error) {
+	n, err := h.ReadAt(p, h.offset)
+	h.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (h *HTTPReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += h.offset
+	case io.SeekEnd:
+		offset += h.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	h.offset = offset
+	return offset, nil
+}
+
+// Close does nothing, each range request is closed once read
+func (h *HTTPReader) Close() error { return nil }
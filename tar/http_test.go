@@ -0,0 +1,101 @@
+package tar
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingWriter count bytes sent by a server
+type countingWriter struct {
+	http.ResponseWriter
+	sent *atomic.Int64
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	w.sent.Add(int64(len(p)))
+	return w.ResponseWriter.Write(p)
+}
+
+func TestHTTPReader(t *testing.T) {
+	big := strings.Repeat("x", 4<<20)
+	archive := test.CreateArchive(t, []test.File{
+		{Name: "app/big.bin", Body: big},
+		{Name: "app/conf", Body: "port=80"},
+	}).Bytes()
+
+	var sent atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(countingWriter{w, &sent}, r, "app.tar", time.Time{}, bytes.NewReader(archive))
+	}))
+	defer server.Close()
+
+	r, err := OpenURL(server.Client(), server.URL)
+	require.Nil(t, err)
+	defer r.Close()
+	require.IsType(t, &HTTPReader{}, r)
+	assert.Equal(t, int64(len(archive)), r.(*HTTPReader).Size())
+
+	root, err := Scan(r, func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+	assert.Less(t, sent.Load(), int64(len(big)), "content of files must not be downloaded on scan")
+
+	conf, err := root.Find("app/conf").ReadData()
+	require.Nil(t, err)
+	assert.Equal(t, "port=80", string(conf))
+
+	data, err := root.Find("app/big.bin").ReadData()
+	require.Nil(t, err)
+	assert.Equal(t, big, string(data))
+}
+
+func TestHTTPReaderWithoutRange(t *testing.T) {
+	archive := test.CreateArchive(t, []test.File{{Name: "app/conf", Body: "port=80"}}).Bytes()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	r, err := OpenURL(server.Client(), server.URL)
+	require.Nil(t, err)
+	defer r.Close()
+	_, ok := r.(*HTTPReader)
+	assert.False(t, ok, "server without range support must be streamed")
+
+	root, err := Scan(r, func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+	assert.Equal(t, "port=80", string(root.Find("app/conf").GetData()))
+}
+
+func TestHTTPReaderReadAt(t *testing.T) {
+	content := []byte("0123456789")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "f", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	r, err := OpenURL(server.Client(), server.URL)
+	require.Nil(t, err)
+	h := r.(*HTTPReader)
+
+	p := make([]byte, 4)
+	n, err := h.ReadAt(p, 8)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, "89", string(p[:n]))
+
+	pos, err := h.Seek(-4, io.SeekEnd)
+	require.Nil(t, err)
+	assert.Equal(t, int64(6), pos)
+	data, err := io.ReadAll(h)
+	require.Nil(t, err)
+	assert.Equal(t, "6789", string(data))
+}
@@ -0,0 +1,141 @@
+package tar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	dockerManifest = "manifest.json" // dockerManifest list images of a `docker save` archive
+	ociIndex       = "index.json"    // ociIndex list images of an OCI layout archive
+	layerIDLen     = 12              // Length of layer ids, like `docker history`
+)
+
+// ociDescriptor reference a blob of an OCI layout
+type ociDescriptor struct {
+	Digest string `json:"digest"`
+}
+
+// blobPath return the path of the blob in an OCI layout (eg: blobs/sha256/<hex>)
+func (d ociDescriptor) blobPath() string {
+	algo, hex, _ := strings.Cut(d.Digest, ":")
+	return path.Join("blobs", algo, hex)
+}
+
+// readJSON decode the json file at path p of the archive into v
+func readJSON(archive *SimpleNode, p string, v any) error {
+	nd := archive.Find(p)
+	if nd == nil || !nd.IsRegular() {
+		return fmt.Errorf("%s not found in image", p)
+	}
+	data, err := nd.ReadData()
+	if err != nil {
+		return fmt.Errorf("error on read %s: %s", p, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error on decode %s: %s", p, err)
+	}
+	return nil
+}
+
+// imageLayers return the paths of the layers of the first image in archive, from the lowest to the top one.
+// manifest.json of `docker save` is used when present, index.json of OCI layout otherwise.
+func imageLayers(archive *SimpleNode) ([]string, error) {
+	if archive.Find(dockerManifest) != nil {
+		var manifest []struct{ Layers []string }
+		if err := readJSON(archive, dockerManifest, &manifest); err != nil {
+			return nil, err
+		}
+		if len(manifest) == 0 {
+			return nil, fmt.Errorf("no image in %s", dockerManifest)
+		}
+		return manifest[0].Layers, nil
+	}
+	if archive.Find(ociIndex) == nil {
+		return nil, fmt.Errorf("not an image archive: %s or %s not found", dockerManifest, ociIndex)
+	}
+
+	p := ociIndex
+	visited := map[string]bool{}
+	for { // Indexes (eg: multi platform images) are followed to their first manifest
+		if visited[p] {
+			return nil, fmt.Errorf("cycle in image indexes: %s is referenced twice", p)
+		}
+		visited[p] = true
+		var manifest struct {
+			Manifests []ociDescriptor `json:"manifests"`
+			Layers    []ociDescriptor `json:"layers"`
+		}
+		if err := readJSON(archive, p, &manifest); err != nil {
+			return nil, err
+		}
+		if len(manifest.Manifests) > 0 {
+			p = manifest.Manifests[0].blobPath()
+			continue
+		}
+		if p == ociIndex {
+			return nil, fmt.Errorf("no image in %s", ociIndex)
+		}
+		layers := make([]string, len(manifest.Layers))
+		for i, l := range manifest.Layers {
+			layers[i] = l.blobPath()
+		}
+		return layers, nil
+	}
+}
+
+// layerID return a short id of the layer at path p, from its digest when possible
+func layerID(p string) string {
+	id := path.Base(p)
+	if id == "layer.tar" { // Legacy `docker save` layout: <id>/layer.tar
+		id = path.Base(path.Dir(p))
+	}
+	if len(id) > layerIDLen {
+		id = id[:layerIDLen]
+	}
+	return id
+}
+
+// ScanImage scan a container image archive, saved with `docker save` or in OCI layout, and return its root filesystem
+// built by stacking the layers of the first image (see Stack). Nodes are annotated with the id of the layer which last wrote them.
+// Like Scan, layers contents are read lazily from r when it can be read at random positions.
+// OnNodeCreation is called on each node of the root filesystem once layers are stacked, whiteouts are applied.
+func ScanImage[T any](r io.Reader, OnNodeCreation func(*Node[T]) error, opts ...StackOption) (*Node[T], error) {
+	archive, err := Scan(r, func(*SimpleNode) error { return nil })
+	if err != nil {
+		return nil, err
+	}
+	paths, err := imageLayers(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := make([]Layer[T], 0, len(paths))
+	for _, p := range paths {
+		nd := archive.Find(p)
+		if nd == nil || !nd.IsRegular() {
+			return nil, fmt.Errorf("layer %s not found in image", p)
+		}
+		rc, err := nd.Open() // Not closed, layer contents can be read lazily from it
+		if err != nil {
+			return nil, fmt.Errorf("error on read layer %s: %s", p, err)
+		}
+		root, err := Scan(rc, func(*Node[T]) error { return nil })
+		if err != nil {
+			return nil, fmt.Errorf("error on scan layer %s: %s", p, err)
+		}
+		layers = append(layers, Layer[T]{Name: layerID(p), Root: root})
+	}
+
+	root := Stack(layers, opts...)
+	if err := OnNodeCreation(root); err != nil {
+		return nil, fmt.Errorf("on node creation: %s", err)
+	}
+	if err := root.OnNestedChildren(OnNodeCreation); err != nil {
+		return nil, fmt.Errorf("on node creation: %s", err)
+	}
+	return root, nil
+}
@@ -0,0 +1,89 @@
+package tar
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// imageLayersFiles are the layers of test images, from the lowest to the top one
+func imageLayersFiles(t *testing.T) []string {
+	return []string{
+		test.CreateArchive(t, []test.File{
+			{Name: "bin/sh", Mode: 0755, Body: "busybox"},
+			{Name: "etc/motd", Mode: 0644, Body: "welcome"},
+		}).String(),
+		test.CreateArchive(t, []test.File{
+			{Name: "etc/motd", Mode: 0644, Body: "hello"},
+			{Name: "app/server", Mode: 0755, Body: "elf"},
+		}).String(),
+	}
+}
+
+func toJSON(t *testing.T, v any) string {
+	data, err := json.Marshal(v)
+	require.Nil(t, err)
+	return string(data)
+}
+
+func TestScanImage(t *testing.T) {
+	layers := imageLayersFiles(t)
+	archives := map[string][]test.File{
+		"Docker": {
+			{Name: "manifest.json", Body: toJSON(t, []map[string]any{{
+				"Config":   "cfg.json",
+				"RepoTags": []string{"app:latest"},
+				"Layers":   []string{"1111111111111111/layer.tar", "2222222222222222/layer.tar"},
+			}})},
+			{Name: "1111111111111111/layer.tar", Body: layers[0]},
+			{Name: "2222222222222222/layer.tar", Body: layers[1]},
+		},
+		"OCI": {
+			{Name: "oci-layout", Body: `{"imageLayoutVersion": "1.0.0"}`},
+			{Name: "index.json", Body: toJSON(t, map[string]any{"manifests": []map[string]string{{"digest": "sha256:aaaa"}}})},
+			{Name: "blobs/sha256/aaaa", Body: toJSON(t, map[string]any{"manifests": []map[string]string{{"digest": "sha256:bbbb"}}})},
+			{Name: "blobs/sha256/bbbb", Body: toJSON(t, map[string]any{"layers": []map[string]string{
+				{"digest": "sha256:1111111111111111"}, {"digest": "sha256:2222222222222222"},
+			}})},
+			{Name: "blobs/sha256/1111111111111111", Body: layers[0]},
+			{Name: "blobs/sha256/2222222222222222", Body: layers[1]},
+		},
+	}
+	for name, files := range archives {
+		t.Run(name, func(t *testing.T) {
+			created := 0
+			root, err := ScanImage(test.CreateArchive(t, files), func(n *SimpleNode) error {
+				created++
+				return nil
+			})
+			require.Nil(t, err)
+			assert.Nil(t, root.Find("manifest.json"))
+			assert.Equal(t, "busybox", string(root.Find("bin/sh").GetData()))
+			assert.Equal(t, "111111111111", root.Find("bin/sh").GetLayer())
+			assert.Equal(t, "hello", string(root.Find("etc/motd").GetData()))
+			assert.Equal(t, "222222222222", root.Find("etc/motd").GetLayer())
+			assert.Equal(t, "elf", string(root.Find("app/server").GetData()))
+			assert.Equal(t, 7, created, "callback is called on root and each stacked node")
+		})
+	}
+}
+
+func TestScanImageErrors(t *testing.T) {
+	_, err := ScanImage(test.CreateArchive(t, []test.File{{Name: "etc/motd", Body: "hello"}}), func(n *SimpleNode) error { return nil })
+	assert.ErrorContains(t, err, "not an image archive")
+
+	_, err = ScanImage(test.CreateArchive(t, []test.File{
+		{Name: "manifest.json", Body: `[{"Layers": ["missing/layer.tar"]}]`},
+	}), func(n *SimpleNode) error { return nil })
+	assert.ErrorContains(t, err, "layer missing/layer.tar not found")
+
+	_, err = ScanImage(test.CreateArchive(t, []test.File{
+		{Name: "index.json", Body: toJSON(t, map[string]any{"manifests": []map[string]string{{"digest": "sha256:aaaa"}}})},
+		{Name: "blobs/sha256/aaaa", Body: toJSON(t, map[string]any{"manifests": []map[string]string{{"digest": "sha256:bbbb"}}})},
+		{Name: "blobs/sha256/bbbb", Body: toJSON(t, map[string]any{"manifests": []map[string]string{{"digest": "sha256:aaaa"}}})},
+	}), func(n *SimpleNode) error { return nil })
+	assert.ErrorContains(t, err, "cycle in image indexes: blobs/sha256/aaaa is referenced twice")
+}
@@ -0,0 +1,61 @@
+package tar
+
+import (
+	"path"
+	"strings"
+)
+
+// cleanName return an archive path without leading "./" or "/" and trailing "/"
+func cleanName(name string) string {
+	name = path.Clean("/" + name)
+	return strings.TrimPrefix(name, "/")
+}
+
+// Match report whether archive path name matches the shell pattern (see path.Match).
+// Pattern is matched against the full path, a "**" element matches zero or more directories (eg: etc/**/*.conf).
+// Leading "./" or "/" are ignored in both pattern and name.
+func Match(pattern, name string) (bool, error) {
+	pattern, name = cleanName(pattern), cleanName(name)
+	if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return false, err // Report bad pattern even if name doesn't match
+	}
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return true, nil
+			}
+			for i := 0; i <= len(name); i++ {
+				if ok, err := matchElems(pattern, name[i:]); ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(name) == 0 {
+			return false, nil
+		}
+		if ok, err := path.Match(pattern[0], name[0]); !ok || err != nil {
+			return false, err
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0, nil
+}
+
+// MatchAny report whether archive path name or one of its parent directories matches one of the patterns,
+// so selecting a directory selects all its content.
+func MatchAny(patterns []string, name string) (bool, error) {
+	for name = cleanName(name); name != ""; name = cleanName(path.Dir(name)) {
+		for _, pattern := range patterns {
+			if ok, err := Match(pattern, name); ok || err != nil {
+				return ok, err
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,58 @@
+package tar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "config.yaml", name: "/config.yaml", expected: true},
+		{pattern: "config.yaml", name: "/app/config.yaml", expected: false},
+		{pattern: "./etc/nginx", name: "/etc/nginx", expected: true},
+		{pattern: "etc/nginx/", name: "etc/nginx", expected: true},
+		{pattern: "etc/*.conf", name: "/etc/app.conf", expected: true},
+		{pattern: "etc/*.conf", name: "/etc/nginx/app.conf", expected: false},
+		{pattern: "etc/**/*.conf", name: "/etc/app.conf", expected: true},
+		{pattern: "etc/**/*.conf", name: "/etc/nginx/sites/app.conf", expected: true},
+		{pattern: "etc/**", name: "/etc/nginx/sites", expected: true},
+		{pattern: "**/config.yaml", name: "/a/b/config.yaml", expected: true},
+		{pattern: "**/config.yaml", name: "/a/b/config.yml", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" on "+tt.name, func(t *testing.T) {
+			ok, err := Match(tt.pattern, tt.name)
+			require.Nil(t, err)
+			assert.Equal(t, tt.expected, ok)
+		})
+	}
+
+	t.Run("Bad pattern", func(t *testing.T) {
+		_, err := Match("etc/[", "/other")
+		assert.Error(t, err)
+		_, err = Match("**/[", "")
+		assert.Error(t, err)
+	})
+
+	t.Run("Match parent directories", func(t *testing.T) {
+		patterns := []string{"etc/nginx", "*.md"}
+		for name, expected := range map[string]bool{
+			"/etc/nginx":                 true,
+			"/etc/nginx/conf.d/app.conf": true,
+			"/etc/nginxfoo":              false,
+			"/etc":                       false,
+			"/README.md":                 true,
+			"/docs/README.md":            false,
+		} {
+			ok, err := MatchAny(patterns, name)
+			require.Nil(t, err)
+			assert.Equal(t, expected, ok, name)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package tar
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NestedSep separate the path of an archive file from the paths of the entries it contains (eg: /bundle.tar!/etc)
+const NestedSep = "!"
+
+// rebase prefix path, name and hardlink target of the node (and its previous versions) with prefix
+func (n *Node[T]) rebase(prefix string) {
+	for _, nd := range append([]*Node[T]{n}, n.history...) {
+		nd.path = prefix + nd.path
+		header := *nd.header
+		header.Name = strings.TrimPrefix(prefix, "/") + "/" + strings.TrimPrefix(header.Name, "/")
+		if header.Typeflag == tar.TypeLink {
+			header.Linkname = strings.TrimPrefix(prefix, "/") + "/" + strings.TrimPrefix(header.Linkname, "/")
+		}
+		nd.header = &header
+		nd.FileInfo = header.FileInfo()
+	}
+}
+
+// IsNested return true if node is a scanned archive file (see ScanNested)
+func (n Node[T]) IsNested() bool {
+	return n.IsRegular() && n.LenChildren() > 0
+}
+
+// isArchiveHead return true if the first bytes of a file are the ones of a tar, zip or compressed archive
+func isArchiveHead(head []byte) bool {
+	if isZip(head) || DetectCompression(head) != None {
+		return true
+	}
+	return len(head) >= sniffLen && bytes.HasPrefix(head[tarMagicOffset:], []byte("ustar"))
+}
+
+// ScanNested scan the content of a regular file as an archive and attach its entries as children of n.
+// Nested paths are prefixed by the path of n and NestedSep (eg: /bundle.tar!/svc/app.tar.gz!/etc),
+// so nested entries are extracted in a "<name>!" directory next to the archive file. Nothing is done if n is already scanned.
+func (n *Node[T]) ScanNested(OnNodeCreation func(*Node[T]) error) error {
+	if n.IsNested() {
+		return nil
+	}
+	entries, err := n.NestedEntries(OnNodeCreation)
+	if err != nil {
+		return err
+	}
+	n.AddNested(entries)
+	return nil
+}
+
+// NestedEntries works like ScanNested but return the entries without attaching them to n, so the tree can be read
+// while scanning (eg: from another goroutine). Files without the magic bytes of an archive are not scanned.
+func (n *Node[T]) NestedEntries(OnNodeCreation func(*Node[T]) error) ([]*Node[T], error) {
+	if !n.IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", n.GetPath())
+	}
+	rc, err := n.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error on read %s: %s", n.GetPath(), err)
+	}
+	head := make([]byte, sniffLen)
+	size, err := io.ReadFull(rc, head)
+	rc.Close()
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("error on read %s: %s", n.GetPath(), err)
+	}
+	if !isArchiveHead(head[:size]) {
+		return nil, fmt.Errorf("%s is not an archive", n.GetPath())
+	}
+
+	rc, err = n.Open() // Not closed, nested contents can be read lazily from it
+	if err != nil {
+		return nil, fmt.Errorf("error on read %s: %s", n.GetPath(), err)
+	}
+	root, err := Scan(rc, OnNodeCreation)
+	if err != nil {
+		return nil, fmt.Errorf("error on scan nested archive %s: %s", n.GetPath(), err)
+	}
+	if root.LenChildren() == 0 { // Any small file is read as an empty archive
+		return nil, fmt.Errorf("%s is not an archive", n.GetPath())
+	}
+	prefix := n.GetPath() + NestedSep
+	_ = root.OnNestedChildren(func(nd *Node[T]) error {
+		nd.rebase(prefix)
+		return nil
+	})
+	return root.GetChildren(), nil
+}
+
+// AddNested attach entries returned by NestedEntries as children of n, nothing is done if n is already scanned
+func (n *Node[T]) AddNested(entries []*Node[T]) {
+	if n.IsNested() {
+		return
+	}
+	for _, child := range entries {
+		n.addChild(child)
+	}
+}
@@ -0,0 +1,88 @@
+package tar
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanNested(t *testing.T) {
+	layer := test.CreateArchive(t, []test.File{
+		{Name: "./etc/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "./etc/app.conf", Mode: 0644, Body: "port: 80"},
+		{Name: "./etc/link.conf", Mode: 0644, Type: tar.TypeLink, Linkname: "./etc/app.conf"},
+	}).Bytes()
+	svc := test.CreateArchive(t, []test.File{{Name: "./layer.tar", Mode: 0644, Body: string(layer)}}).Bytes()
+	bundle := test.CreateArchive(t, []test.File{
+		{Name: "./svc.tar.gz", Mode: 0644, Body: compress(t, Gzip, svc).String()},
+		{Name: "./readme.txt", Mode: 0644, Body: "not an archive"},
+	})
+	root, err := Scan(bundle, func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+	svcNode, readme := root.GetChildren()[0], root.GetChildren()[1]
+
+	t.Run("Not an archive", func(t *testing.T) {
+		assert.NotNil(t, readme.ScanNested(func(n *SimpleNode) error { return nil }))
+		assert.False(t, readme.IsNested())
+	})
+
+	t.Run("Entries are scanned without attaching them", func(t *testing.T) {
+		entries, err := svcNode.NestedEntries(func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "/svc.tar.gz!/layer.tar", entries[0].GetPath())
+		assert.False(t, svcNode.IsNested())
+	})
+
+	t.Run("Nested archives", func(t *testing.T) {
+		require.Nil(t, svcNode.ScanNested(func(n *SimpleNode) error { return nil }))
+		require.True(t, svcNode.IsNested())
+		layerNode := svcNode.GetChildren()[0]
+		assert.Equal(t, "/svc.tar.gz!/layer.tar", layerNode.GetPath())
+		require.Nil(t, layerNode.ScanNested(func(n *SimpleNode) error { return nil }))
+		conf := layerNode.GetChildren()[0].GetChildren()[0]
+		assert.Equal(t, "/svc.tar.gz!/layer.tar!/etc/app.conf", conf.GetPath())
+		assert.Equal(t, "app.conf", conf.Name())
+		assert.Equal(t, "port: 80", string(conf.GetData()))
+		assert.Equal(t, svcNode, conf.GetParent().GetParent().GetParent())
+		require.Nil(t, svcNode.ScanNested(func(n *SimpleNode) error { return nil })) // Already scanned
+		assert.Equal(t, 1, svcNode.LenChildren())
+	})
+
+	t.Run("Extract nested entries", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.Nil(t, Extract(root, tmpDir, func(n *SimpleNode) bool { return false }))
+		assert.FileExists(t, getExtractedPath(tmpDir, "svc.tar.gz"))
+		assert.FileExists(t, getExtractedPath(tmpDir, "svc.tar.gz!/layer.tar"))
+		data, err := os.ReadFile(getExtractedPath(tmpDir, "svc.tar.gz!/layer.tar!/etc/link.conf"))
+		require.Nil(t, err)
+		assert.Equal(t, "port: 80", string(data))
+		assert.DirExists(t, filepath.Dir(getExtractedPath(tmpDir, "svc.tar.gz!/layer.tar!/etc/app.conf")))
+	})
+}
+
+func TestIsArchiveHead(t *testing.T) {
+	archive := test.CreateArchive(t, []test.File{{Name: "a", Mode: 0644, Body: "a"}}).Bytes()
+	tests := []struct {
+		name string
+		head []byte
+		want bool
+	}{
+		{name: "tar", head: archive[:sniffLen], want: true},
+		{name: "gzip", head: compress(t, Gzip, archive).Bytes()[:10], want: true},
+		{name: "zip", head: []byte("PK\x03\x04rest"), want: true},
+		{name: "text", head: []byte("hello world"), want: false},
+		{name: "short tar", head: archive[:100], want: false},
+		{name: "empty", head: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isArchiveHead(tt.head))
+		})
+	}
+}
@@ -2,10 +2,14 @@ package tar
 
 import (
 	"archive/tar"
+	"bytes"
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"io/fs"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -14,18 +18,85 @@ type SimpleNode = Node[struct{}]
 type Node[T any] struct {
 	fs.FileInfo
 	header   *tar.Header
-	path     string     // path is the unique id of the node
-	parent   *Node[T]   // parent is the direct parent of actual node, nil if node is root
-	children []*Node[T] // children are all childs under this node
-	data     []byte     // data is the content of file, empty if not a file
-	Spec     T          // Spec is the additionalData that users can set on node creation
+	path     string                        // path is the unique id of the node
+	parent   *Node[T]                      // parent is the direct parent of actual node, nil if node is root
+	children []*Node[T]                    // children are all childs under this node
+	data     []byte                        // data is the content of file, empty if not a file or if loaded lazily
+	open     func() (io.ReadCloser, error) // open read content from archive, nil if data is in memory
+	implicit bool                          // implicit is true for directories missing in the archive, created to hold children
+	version  int                           // version is the occurrence (starting at 0) of the path in the archive used by this node
+	history  []*Node[T]                    // history keeps other occurrences of the same path in the archive
+	layer    string                        // layer is the name of the layer which wrote the node in a stacked tree
+	Spec     T                             // Spec is the additionalData that users can set on node creation
 }
 
 func (n Node[T]) GetPath() string         { return n.path }               // Id and full path of Node
 func (n Node[T]) GetChildren() []*Node[T] { return n.children }           // GetChildren return the node's children
 func (n Node[T]) LenChildren() int        { return len(n.GetChildren()) } // Get size children of current Node
 func (n Node[T]) IsRoot() bool            { return n.parent == nil }      // Node is root if no parents
-func (n Node[T]) GetData() []byte         { return n.data }               // Get data (used for files, other are empty)
+func (n Node[T]) GetHeader() *tar.Header  { return n.header }             // Header from archive, nil if node is root
+func (n Node[T]) IsImplicit() bool        { return n.implicit }           // Directory not stored in archive but parent of an entry
+func (n Node[T]) GetVersion() int         { return n.version }            // Occurrence (starting at 0) of the path in the archive
+func (n Node[T]) GetLayer() string        { return n.layer }              // Layer which last wrote the node in a stacked tree (see Stack)
+
+// IsRegular return true if node is a regular file with content in the archive, hardlinks excluded
+func (n Node[T]) IsRegular() bool {
+	if n.header == nil {
+		return false
+	}
+	switch n.header.Typeflag {
+	case tar.TypeReg, tar.TypeRegA, tar.TypeGNUSparse:
+		return true
+	}
+	return false
+}
+
+// GetData return the content of file (other types are empty).
+// Lazily loaded content is read from the archive, use ReadData to get the read error.
+func (n Node[T]) GetData() []byte {
+	data, _ := n.ReadData()
+	return data
+}
+
+// ReadData return the content of file, reading it from the archive if it was not loaded in memory
+func (n Node[T]) ReadData() ([]byte, error) {
+	if n.open == nil {
+		return n.data, nil
+	}
+	rc, err := n.open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// sectionReadCloser is a seekable reader on the content of a file
+type sectionReadCloser struct{ *io.SectionReader }
+
+func (sectionReadCloser) Close() error { return nil }
+
+// Open return a reader on the content of file, it implements io.Seeker and io.ReaderAt when content is stored uncompressed
+func (n Node[T]) Open() (io.ReadCloser, error) {
+	if n.open == nil {
+		return sectionReadCloser{io.NewSectionReader(bytes.NewReader(n.data), 0, int64(len(n.data)))}, nil
+	}
+	return n.open()
+}
+
+// Hash return the sha256 of file content
+func (n Node[T]) Hash() ([]byte, error) {
+	rc, err := n.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
 
 // Get Root Node from current node
 func (n *Node[T]) GetRoot() *Node[T] {
@@ -74,35 +145,160 @@ func (n *Node[T]) addChild(node *Node[T]) {
 	n.children = append(n.children, node)
 }
 
-// addChildFromHeader add Child to node based on header name
+// getChild return the direct child with given path, nil if not found
+func (n *Node[T]) getChild(path string) *Node[T] {
+	for _, child := range n.GetChildren() {
+		if child.GetPath() == path {
+			return child
+		}
+	}
+	return nil
+}
+
+// Find return the nested node at given path, relative to this node. Nil if not found
+func (n *Node[T]) Find(p string) *Node[T] {
+	current := n
+	target := filepath.Join(n.GetPath(), p)
+	rel := strings.TrimPrefix(strings.TrimPrefix(target, n.GetPath()), "/")
+	if rel == "" {
+		return n
+	}
+	currentPath := n.GetPath()
+	for _, name := range strings.Split(rel, "/") {
+		currentPath = filepath.Join(currentPath, name)
+		if current = current.getChild(currentPath); current == nil {
+			return nil
+		}
+	}
+	return current
+}
+
+// addParents create missing parent directories of header name, created nodes are returned.
+// Archives (eg: created with a list of files) can omit directories entries.
+func (n *Node[T]) addParents(header *tar.Header) []*Node[T] {
+	var created []*Node[T]
+	current := n
+	dir := filepath.Dir(filepath.Join("/", header.Name))
+	if dir == "/" {
+		return nil
+	}
+	for _, name := range strings.Split(strings.TrimPrefix(dir, "/"), "/") {
+		childPath := filepath.Join(current.GetPath(), name)
+		child := current.getChild(childPath)
+		if child == nil {
+			child = newNode[T](&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     strings.TrimPrefix(strings.TrimPrefix(childPath, n.GetPath()), "/") + "/",
+				Mode:     0755,
+				ModTime:  header.ModTime,
+			}, childPath)
+			child.implicit = true
+			current.addChild(child)
+			created = append(created, child)
+		} else if !child.IsDir() {
+			return created
+		}
+		current = child
+	}
+	return created
+}
+
+// addChildFromHeader add Child to node based on header name.
+// If path already exist, the existing node is returned with a NodeExistError (nil for the node itself).
 func (n *Node[T]) addChildFromHeader(header *tar.Header) (*Node[T], error) {
 	path := filepath.Join(n.GetPath(), header.Name) // Sanitize path
 	nd := newNode[T](header, path)
+	if path == n.GetPath() {
+		return nil, NodeExistError{path: path}
+	}
 
-	queue := []*Node[T]{n}
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-		if current.GetPath() == path { // Return an error if path already exist
-			return nil, NodeExistError{path: path}
-		}
-		if current.GetPath() == filepath.Dir(path) { // link node to parent
-			current.addChild(nd)
-			return nd, nil
-		}
-		queue = append(queue, current.GetDirChildren()...) // List only directory childs
+	parent := n.Find(strings.TrimPrefix(filepath.Dir(path), n.GetPath()))
+	if parent == nil || !parent.IsDir() { // Parent was replaced by another type of entry, node is kept under this node
+		parent = n
 	}
-	n.addChild(nd)
+	if existing := parent.getChild(path); existing != nil { // Return an error if path already exist
+		return existing, NodeExistError{path: path}
+	}
+	parent.addChild(nd) // link node to parent
 	return nd, nil
 }
 
+// GetVersions return all occurrences of the node path in the archive ordered as in the archive,
+// the node itself is in the list at its version index. Nil if the path appears once in the archive.
+func (n *Node[T]) GetVersions() []*Node[T] {
+	if len(n.history) == 0 {
+		return nil
+	}
+	versions := make([]*Node[T], len(n.history)+1)
+	versions[n.version] = n
+	for _, h := range n.history {
+		versions[h.version] = h
+	}
+	return versions
+}
+
+// SelectVersion use another occurrence of the path in the archive as node content (header, file info and data).
+// Children and Spec are kept.
+func (n *Node[T]) SelectVersion(version int) error {
+	for i, h := range n.history {
+		if h.version == version {
+			n.history[i] = n.snapshot()
+			n.setContent(h)
+			return nil
+		}
+	}
+	if version != n.version {
+		return fmt.Errorf("version %d of %s does not exist", version, n.GetPath())
+	}
+	return nil
+}
+
+// snapshot return a copy of node content, without children
+func (n *Node[T]) snapshot() *Node[T] {
+	return &Node[T]{
+		FileInfo: n.FileInfo,
+		header:   n.header,
+		path:     n.path,
+		parent:   n.parent,
+		data:     n.data,
+		open:     n.open,
+		version:  n.version,
+		layer:    n.layer,
+		Spec:     n.Spec,
+	}
+}
+
+// setContent copy content of another node
+func (n *Node[T]) setContent(from *Node[T]) {
+	n.FileInfo = from.FileInfo
+	n.header = from.header
+	n.data = from.data
+	n.open = from.open
+	n.version = from.version
+	n.layer = from.layer
+}
+
+// replace set header as the node content, like tar the last occurrence of a path wins and previous ones are kept in history.
+// A directory replaced by another type of entry loses its children.
+func (n *Node[T]) replace(header *tar.Header) {
+	if n.implicit { // Directory was created for its children, it's not a previous occurrence
+		n.implicit = false
+	} else {
+		n.history = append(n.history, n.snapshot())
+	}
+	n.setContent(newNode[T](header, n.path))
+	n.version = len(n.history)
+	if !n.IsDir() {
+		n.children = nil
+	}
+}
+
 // newNode return a node with default values
 func newNode[T any](header *tar.Header, path string) *Node[T] {
 	return &Node[T]{
 		header:   header,
 		FileInfo: header.FileInfo(),
 		path:     path,
-		data:     make([]byte, header.Size),
 	}
 }
 
@@ -45,3 +45,37 @@ func Test_Node(t *testing.T) {
 		assert.Equal(t, td, root.GetData())
 	})
 }
+
+func Test_FindNode(t *testing.T) {
+	root := newRootNode[struct{}]()
+	for _, header := range []*tar.Header{
+		{Name: "a/b/c.txt", Typeflag: tar.TypeReg},
+		{Name: "a/d.txt", Typeflag: tar.TypeReg},
+	} {
+		parents := root.addParents(header)
+		for _, p := range parents {
+			assert.True(t, p.IsImplicit())
+			assert.True(t, p.IsDir())
+		}
+		_, err := root.addChildFromHeader(header)
+		require.Nil(t, err)
+	}
+
+	t.Run("Implicit parents are created once", func(t *testing.T) {
+		require.Equal(t, 1, root.LenChildren())
+		assert.Equal(t, 2, root.Find("a").LenChildren())
+	})
+
+	t.Run("Find nested node", func(t *testing.T) {
+		assert.Equal(t, "/a/b/c.txt", root.Find("a/b/c.txt").GetPath())
+		assert.Equal(t, "/a/b/c.txt", root.Find("/a/b/c.txt").GetPath())
+		assert.Equal(t, "/a/b/c.txt", root.Find("a").Find("b/c.txt").GetPath())
+		assert.Equal(t, root, root.Find("."))
+		assert.Nil(t, root.Find("a/missing"))
+	})
+
+	t.Run("Existing path raise an error", func(t *testing.T) {
+		_, err := root.addChildFromHeader(&tar.Header{Name: "./a/d.txt"})
+		assert.IsType(t, NodeExistError{}, err)
+	})
+}
@@ -0,0 +1,112 @@
+package tar
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	whiteoutPrefix = ".wh."         // whiteoutPrefix mark the deletion of the file named after it in lower layers
+	whiteoutOpaque = ".wh..wh..opq" // whiteoutOpaque mark its directory as opaque, children of lower layers are hidden
+)
+
+// Layer is a scanned archive to stack over other ones with Stack
+type Layer[T any] struct {
+	Name string   // Name identify the layer on stacked nodes (see Node.GetLayer)
+	Root *Node[T] // Root is the root directory node of the scanned archive
+}
+
+type stacker struct {
+	rawWhiteouts bool
+}
+
+type StackOption func(*stacker)
+
+// WithRawWhiteouts keep whiteout entries in the stacked tree, to see which layer deleted a path.
+// Whiteouts are still applied to lower layers.
+func WithRawWhiteouts() StackOption {
+	return func(s *stacker) { s.rawWhiteouts = true }
+}
+
+// IsWhiteout return true if node is an overlayfs whiteout entry (.wh.<name> or .wh..wh..opq)
+func (n Node[T]) IsWhiteout() bool {
+	return !n.IsRoot() && strings.HasPrefix(n.Name(), whiteoutPrefix)
+}
+
+// Stack merge the trees of layers in order into a new tree, like the root filesystem of a container built from its layers.
+// When a path is in several layers the last one wins, previous ones are kept as versions of the node (see Node.GetVersions).
+// Directories are merged, a directory replaced by another type of entry loses its children.
+// Whiteouts of a layer delete paths of lower layers: .wh.<name> deletes <name> and .wh..wh..opq deletes all children
+// of its directory, whiteout entries are not part of the result unless WithRawWhiteouts is used.
+// Nodes of the result share content with nodes of the layers, so layers archives must stay open while using the tree.
+func Stack[T any](layers []Layer[T], opts ...StackOption) *Node[T] {
+	s := &stacker{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	root := newRootNode[T]()
+	for _, l := range layers {
+		_ = l.Root.OnNestedChildren(func(nd *Node[T]) error {
+			if nd.IsWhiteout() {
+				root.whiteout(nd)
+			}
+			return nil
+		})
+		_ = l.Root.OnNestedChildren(func(nd *Node[T]) error { // Parents are visited before their children
+			if !nd.IsWhiteout() || s.rawWhiteouts {
+				root.stack(nd, l.Name)
+			}
+			return nil
+		})
+	}
+	return root
+}
+
+// whiteout delete from the tree of n the path hidden by whiteout entry nd
+func (n *Node[T]) whiteout(nd *Node[T]) {
+	dir := n.Find(filepath.Dir(nd.GetPath()))
+	if dir == nil {
+		return
+	}
+	if nd.Name() == whiteoutOpaque {
+		dir.children = nil
+		return
+	}
+	hidden := filepath.Join(dir.GetPath(), strings.TrimPrefix(nd.Name(), whiteoutPrefix))
+	for i, child := range dir.children {
+		if child.GetPath() == hidden {
+			dir.children = append(dir.children[:i], dir.children[i+1:]...)
+			return
+		}
+	}
+}
+
+// stack add a copy of nd from given layer in the tree of n, replacing the node with the same path
+func (n *Node[T]) stack(nd *Node[T], layer string) {
+	parent := n.Find(filepath.Dir(nd.GetPath()))
+	if parent == nil || !parent.IsDir() { // Parent was replaced by a file in this layer
+		return
+	}
+	top := nd.snapshot()
+	top.version = 0
+	top.layer = layer
+	top.implicit = nd.implicit
+
+	current := parent.getChild(nd.GetPath())
+	if current == nil {
+		parent.addChild(top)
+		return
+	}
+	if nd.implicit && current.IsDir() { // Layer only holds children of the directory
+		return
+	}
+	if !current.implicit {
+		current.history = append(current.history, current.snapshot())
+	}
+	current.setContent(top)
+	current.implicit = top.implicit
+	current.version = len(current.history)
+	if !current.IsDir() {
+		current.children = nil
+	}
+}
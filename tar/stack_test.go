@@ -0,0 +1,114 @@
+package tar
+
+import (
+	"testing"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanLayer return a layer from an archive created with files
+func scanLayer(t *testing.T, name string, files []test.File) Layer[struct{}] {
+	root, err := Scan(test.CreateArchive(t, files), func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+	return Layer[struct{}]{Name: name, Root: root}
+}
+
+func TestStack(t *testing.T) {
+	root := Stack([]Layer[struct{}]{
+		scanLayer(t, "base", []test.File{
+			{Name: "etc/", Mode: 0755},
+			{Name: "etc/os-release", Mode: 0644, Body: "debian"},
+			{Name: "etc/hosts", Mode: 0644, Body: "localhost"},
+			{Name: "opt/app", Mode: 0644, Body: "file"},
+		}),
+		scanLayer(t, "app", []test.File{
+			{Name: "etc/os-release", Mode: 0644, Body: "alpine"},
+			{Name: "etc/app/config", Mode: 0600, Body: "port=80"},
+			{Name: "opt/app/", Mode: 0700},
+			{Name: "opt/app/bin", Mode: 0755, Body: "elf"},
+		}),
+	})
+
+	etc := root.Find("etc")
+	require.NotNil(t, etc)
+	assert.False(t, etc.IsImplicit(), "implicit directory of upper layer must not hide the directory of lower layer")
+	assert.Equal(t, "base", etc.GetLayer())
+
+	release := root.Find("etc/os-release")
+	assert.Equal(t, "alpine", string(release.GetData()))
+	assert.Equal(t, "app", release.GetLayer())
+	require.Len(t, release.GetVersions(), 2)
+	assert.Equal(t, "base", release.GetVersions()[0].GetLayer())
+	require.Nil(t, release.SelectVersion(0))
+	assert.Equal(t, "debian", string(release.GetData()))
+
+	assert.Equal(t, "localhost", string(root.Find("etc/hosts").GetData()))
+	assert.Equal(t, "base", root.Find("etc/hosts").GetLayer())
+	assert.Equal(t, "port=80", string(root.Find("etc/app/config").GetData()))
+
+	app := root.Find("opt/app")
+	assert.True(t, app.IsDir(), "file replaced by a directory")
+	assert.Equal(t, "elf", string(root.Find("opt/app/bin").GetData()))
+}
+
+func TestStackReplaceDirectory(t *testing.T) {
+	root := Stack([]Layer[struct{}]{
+		scanLayer(t, "base", []test.File{{Name: "var/cache/apt/pkg", Body: "deb"}}),
+		scanLayer(t, "app", []test.File{{Name: "var/cache", Type: '2', Linkname: "/tmp"}}),
+	})
+	cache := root.Find("var/cache")
+	require.NotNil(t, cache)
+	assert.Equal(t, "/tmp", cache.GetHeader().Linkname)
+	assert.Equal(t, 0, cache.LenChildren(), "children of replaced directory must be removed")
+}
+
+func TestStackWhiteouts(t *testing.T) {
+	layers := []Layer[struct{}]{
+		scanLayer(t, "base", []test.File{
+			{Name: "etc/motd", Body: "welcome"},
+			{Name: "etc/hosts", Body: "localhost"},
+			{Name: "var/cache/apt/pkg", Body: "deb"},
+			{Name: "var/cache/index", Body: "idx"},
+			{Name: "tmp/build/", Mode: 0755},
+		}),
+		scanLayer(t, "clean", []test.File{
+			{Name: "etc/.wh.motd"},
+			{Name: "var/cache/.wh..wh..opq"},
+			{Name: "var/cache/new", Body: "fresh"},
+			{Name: "tmp/.wh.build"},
+			{Name: "tmp/build", Body: "now a file"},
+			{Name: ".wh.missing"},
+		}),
+	}
+
+	t.Run("Applied", func(t *testing.T) {
+		root := Stack(layers)
+		assert.Nil(t, root.Find("etc/motd"))
+		assert.Nil(t, root.Find("etc/.wh.motd"))
+		assert.NotNil(t, root.Find("etc/hosts"))
+
+		cache := root.Find("var/cache")
+		require.NotNil(t, cache)
+		require.Equal(t, 1, cache.LenChildren(), "opaque directory hides children of lower layers only")
+		assert.Equal(t, "fresh", string(root.Find("var/cache/new").GetData()))
+
+		build := root.Find("tmp/build")
+		require.NotNil(t, build)
+		assert.Equal(t, "now a file", string(build.GetData()))
+		assert.Nil(t, build.GetVersions(), "deleted path is not a previous version")
+	})
+
+	t.Run("Raw", func(t *testing.T) {
+		root := Stack(layers, WithRawWhiteouts())
+		assert.Nil(t, root.Find("etc/motd"))
+		whiteout := root.Find("etc/.wh.motd")
+		require.NotNil(t, whiteout)
+		assert.True(t, whiteout.IsWhiteout())
+		assert.Equal(t, "clean", whiteout.GetLayer())
+		assert.NotNil(t, root.Find("var/cache/.wh..wh..opq"))
+		assert.Equal(t, 2, root.Find("var/cache").LenChildren())
+		assert.NotNil(t, root.Find(".wh.missing"))
+	})
+}
@@ -0,0 +1,59 @@
+package tar
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+)
+
+// Stream read archive entries one by one without building the tree, so memory use doesn't depend on archive size
+// (except for zip archives read from a non seekable reader, see NewArchiveReader).
+// cb is called for each entry with a node detached from any tree (no parent nor children),
+// node content can be read with Open only until cb returns. Return fs.SkipAll from cb to stop reading the archive.
+func Stream(r io.Reader, cb func(*SimpleNode) error) error {
+	ar, err := NewArchiveReader(r)
+	if err != nil {
+		return err
+	}
+	for {
+		header, err := ar.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("on reading archive %s", err)
+		}
+		path := filepath.Join("/", header.Name) // Sanitize path
+		if path == "/" {
+			continue
+		}
+		nd := newNode[struct{}](header, path)
+		nd.open = func() (io.ReadCloser, error) { return io.NopCloser(ar), nil }
+		if err := cb(nd); err == fs.SkipAll {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// ExtractStream extract entries while reading the archive, it works on non seekable readers (eg: pipes)
+// and memory use doesn't depend on archive size. Behaviour is the same as Extract,
+// isSkipped receives nodes detached from the tree (see Stream).
+func ExtractStream(r io.Reader, outputPath string, isSkipped func(*SimpleNode) bool, opts ...ExtractOption) error {
+	e, err := newExtractor(outputPath, opts...)
+	if err != nil {
+		return err
+	}
+	err = Stream(r, func(nd *SimpleNode) error {
+		if !isSkipped(nd) {
+			e.report(nd.GetPath(), e.extractEntry(nd.GetPath(), nd.header, nd.Open))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return e.err()
+}
@@ -4,20 +4,15 @@ import (
 	"archive/tar"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
 )
 
-const ExtractFolder = "guntar_extracted"
-
-func scan[T any](r io.Reader, OnNodeCreation func(*Node[T]) error, readData bool) (*Node[T], error) {
-	tr := tar.NewReader(r)
+func scan[T any](ar ArchiveReader, OnNodeCreation func(*Node[T]) error, readData bool) (*Node[T], error) {
 	root := newRootNode[T]()
 	if err := OnNodeCreation(root); err != nil {
 		return nil, fmt.Errorf("on node creation: %s", err)
 	}
 	for {
-		header, err := tr.Next()
+		header, err := ar.Next()
 		if err != nil {
 			if err == io.EOF {
 				return root, nil
@@ -25,13 +20,23 @@ func scan[T any](r io.Reader, OnNodeCreation func(*Node[T]) error, readData bool
 			return nil, fmt.Errorf("on reading archive %s", err)
 		}
 
+		for _, parent := range root.addParents(header) {
+			if err := OnNodeCreation(parent); err != nil {
+				return nil, fmt.Errorf("on node creation: %s", err)
+			}
+		}
 		nf, err := root.addChildFromHeader(header)
-		if _, ok := err.(NodeExistError); ok { // If path already exist, stop and continue
-			continue
+		if _, ok := err.(NodeExistError); ok {
+			if nf == nil { // Root directory entry is ignored
+				continue
+			}
+			nf.replace(header) // Last occurrence wins like tar
 		}
-		if readData {
-			// Read data (Read returns directly 0,io.EOF if not TypeReg)
-			if _, err := tr.Read(nf.data); err != nil && err != io.EOF {
+		if open := ar.Lazy(); open != nil {
+			nf.open = open // Data is read from the archive only when asked
+		} else if readData && header.Typeflag != tar.TypeDir {
+			nf.data = make([]byte, header.Size)
+			if _, err := io.ReadFull(ar, nf.data); err != nil && err != io.EOF {
 				return nil, fmt.Errorf("on reading file: %s", err)
 			}
 		}
@@ -42,22 +47,51 @@ func scan[T any](r io.Reader, OnNodeCreation func(*Node[T]) error, readData bool
 	}
 }
 
-// Scan through a reader (file,string,etc...) with a tar archive and return the root directory node of the archive,
+// Scan through a reader (file,string,etc...) with a tar or zip archive and return the root directory node of the archive,
+// compressed tar archives (gzip, bzip2, xz, zstd) are detected and decompressed on the fly.
+// Parent directories missing in the archive are created as implicit directories (see Node.IsImplicit), they are not listed by List
+// and only created on extraction to hold their children.
+// When a path appears several times in the archive, the last occurrence is used and previous ones are kept as versions
+// of the node (see Node.GetVersions), OnNodeCreation is then called again on the node. A directory replaced by another type
+// of entry loses its children.
+// If r is an uncompressed tar or a zip archive implementing io.ReaderAt and io.Seeker (eg: *os.File), only headers are kept
+// in memory, files content are read from r when asked with Node.Open, so r must stay open while using the tree.
 // Node is a generic type, you can implement it with the callback Node type eg: func(n *Node[struct{}])
 // The type T is used to add additionnal data into each nodes on creation. It let the possibility to initialize each node.
 func Scan[T any](r io.Reader, OnNodeCreation func(*Node[T]) error) (*Node[T], error) {
-	return scan(r, OnNodeCreation, true)
+	ar, err := NewArchiveReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return scan(ar, OnNodeCreation, true)
 }
 
-// List through archive to extract all headers name
+// ScanHeaders works like Scan without loading files content, only headers are read from the archive
+func ScanHeaders[T any](r io.Reader, OnNodeCreation func(*Node[T]) error) (*Node[T], error) {
+	ar, err := NewArchiveReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return scan(ar, OnNodeCreation, false)
+}
+
+// ScanArchive works like Scan with entries read from ar, it can be used to scan other archive formats
+func ScanArchive[T any](ar ArchiveReader, OnNodeCreation func(*Node[T]) error) (*Node[T], error) {
+	return scan(ar, OnNodeCreation, true)
+}
+
+// List through archive to extract all headers name, implicit directories are not listed
 func List(r io.Reader) ([]string, error) {
-	root, err := scan(r, func(*Node[struct{}]) error { return nil }, false)
+	root, err := ScanHeaders(r, func(*Node[struct{}]) error { return nil })
 	if err != nil {
 		return nil, err
 	}
 
 	list := []string{}
 	err = root.OnNestedChildren(func(nd *Node[struct{}]) error {
+		if nd.IsImplicit() {
+			return nil
+		}
 		list = append(list, nd.GetPath())
 		return nil
 	})
@@ -67,39 +101,3 @@ func List(r io.Reader) ([]string, error) {
 
 	return list, nil
 }
-
-// Extract all nodes to the output file.
-// isSkipped callback can be used to add logic (skip current node if true) on nodes extraction
-func Extract[T any](node *Node[T], outputPath string, isSkipped func(*Node[T]) bool) error {
-	if len(outputPath) == 0 {
-		var err error
-		outputPath, err = os.Getwd()
-		if err != nil {
-			return fmt.Errorf("error on get current directory: %s", err)
-		}
-	}
-	outputPath = filepath.Join(outputPath, ExtractFolder)
-	if err := os.Mkdir(outputPath, 0777); os.IsExist(err) {
-		return fmt.Errorf("error on create extract directory %s: %s", outputPath, err)
-	}
-	return node.OnNestedChildren(func(nd *Node[T]) error {
-		if isSkipped(nd) {
-			return nil
-		}
-
-		dirPath := filepath.Join(outputPath, nd.GetParent().GetPath())
-		if !nd.IsDir() && nd.Mode().IsRegular() {
-			if _, err := os.Stat(dirPath); os.IsNotExist(err) {
-				err := os.MkdirAll(dirPath, 0777) //TODO change me to use permissions from archive?
-				if err != nil {
-					return fmt.Errorf("error on create directory %s: %s", dirPath, err)
-				}
-			}
-			filePath := filepath.Join(dirPath, nd.Name())
-			if err := os.WriteFile(filePath, nd.GetData(), nd.Mode().Perm()); err != nil {
-				return fmt.Errorf("error on create file %s: %s", filePath, err)
-			}
-		}
-		return nil
-	})
-}
@@ -1,6 +1,10 @@
 package tar
 
 import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -150,6 +154,43 @@ func TestScanWithArchiveFile(t *testing.T) {
 	}
 }
 
+func TestScanWithoutDirectoryEntries(t *testing.T) {
+	files := []test.File{
+		{Name: "./etc/nginx/nginx.conf", Mode: 0600, Body: "worker_processes 1;"},
+		{Name: "./etc/hosts", Mode: 0644, Body: "127.0.0.1 localhost"},
+		{Name: "./readme.txt", Mode: 0644, Body: "hello"},
+	}
+
+	t.Run("Parents are created as implicit directories", func(t *testing.T) {
+		root, err := Scan(test.CreateArchive(t, files), func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+		require.Equal(t, 2, root.LenChildren()) // etc, readme.txt
+		etc := root.Find("etc")
+		require.NotNil(t, etc)
+		assert.True(t, etc.IsDir())
+		assert.True(t, etc.IsImplicit())
+		assert.Equal(t, etc, root.Find("etc/nginx").GetParent())
+		assert.Equal(t, root.Find("etc/nginx"), root.Find("etc/nginx/nginx.conf").GetParent())
+	})
+
+	t.Run("List only archive entries", func(t *testing.T) {
+		list, err := List(test.CreateArchive(t, files))
+		require.Nil(t, err)
+		assert.Equal(t, []string{"/etc/nginx/nginx.conf", "/etc/hosts", "/readme.txt"}, list)
+	})
+
+	t.Run("Extract creates implicit directories with their children", func(t *testing.T) {
+		root, err := Scan(test.CreateArchive(t, files), func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+		tmpDir := t.TempDir()
+		require.Nil(t, Extract(root, tmpDir, func(n *SimpleNode) bool { return n.GetPath() == "/etc/hosts" }))
+		data, err := os.ReadFile(getExtractedPath(tmpDir, "etc/nginx/nginx.conf"))
+		require.Nil(t, err)
+		assert.Equal(t, files[0].Body, string(data))
+		assert.NoFileExists(t, getExtractedPath(tmpDir, "etc/hosts"))
+	})
+}
+
 func TestPathTraversal(t *testing.T) {
 	fileName := "exploit_test.txt"
 	files := []test.File{
@@ -178,3 +219,177 @@ func TestPathTraversal(t *testing.T) {
 		assert.FileExists(t, getExtractedPath(tmpDir, expectedPath))
 	})
 }
+
+func TestLazyScan(t *testing.T) {
+	files := []test.File{
+		{Name: "./test/", Mode: fs.ModeDir, Body: ""},
+		{Name: "./test/readme.txt", Mode: 0600, Body: "This archive contains some text files."},
+		{Name: "gopher.txt", Mode: 0600, Body: "Gopher names:\nGeorge\nGeoffrey\nGonzo"},
+	}
+	archive := test.CreateArchive(t, files).Bytes()
+
+	t.Run("Content is not loaded from seekable reader", func(t *testing.T) {
+		root, err := Scan(bytes.NewReader(archive), func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+		gopher := root.GetChildren()[1]
+		assert.Nil(t, gopher.data)
+		assert.NotNil(t, gopher.open)
+		data, err := gopher.ReadData()
+		require.Nil(t, err)
+		assert.Equal(t, files[2].Body, string(data))
+		readme := root.GetChildren()[0].GetChildren()[0]
+		assert.Equal(t, files[1].Body, string(readme.GetData()))
+	})
+
+	t.Run("Content is loaded from stream", func(t *testing.T) {
+		root, err := Scan(bytes.NewBuffer(archive), func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+		gopher := root.GetChildren()[1]
+		assert.Nil(t, gopher.open)
+		assert.Equal(t, files[2].Body, string(gopher.data))
+	})
+
+	t.Run("Scan headers only", func(t *testing.T) {
+		root, err := ScanHeaders(bytes.NewBuffer(archive), func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+		gopher := root.GetChildren()[1]
+		assert.Nil(t, gopher.data)
+		assert.Equal(t, int64(len(files[2].Body)), gopher.Size())
+		assert.Equal(t, "gopher.txt", gopher.GetHeader().Name)
+	})
+
+	t.Run("Extract lazy archive", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		f, err := os.CreateTemp(tmpDir, "*.tar")
+		require.Nil(t, err)
+		_, err = f.Write(archive)
+		require.Nil(t, err)
+		_, err = f.Seek(0, io.SeekStart)
+		require.Nil(t, err)
+		defer f.Close()
+
+		root, err := Scan(f, func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+		require.Nil(t, Extract(root, tmpDir, func(n *SimpleNode) bool { return false }))
+		data, err := os.ReadFile(getExtractedPath(tmpDir, "gopher.txt"))
+		require.Nil(t, err)
+		assert.Equal(t, files[2].Body, string(data))
+	})
+}
+
+func TestDuplicateEntries(t *testing.T) {
+	files := []test.File{
+		{Name: "./app/config.yaml", Mode: 0600, Body: "version: 1"},
+		{Name: "./app/", Mode: 0755, Type: tar.TypeDir},
+		{Name: "./app/config.yaml", Mode: 0600, Body: "version: 2"},
+		{Name: "./app/config.yaml", Mode: 0644, Body: "version: 3"},
+	}
+	for name, r := range map[string]func() io.Reader{
+		"stream":   func() io.Reader { return test.CreateArchive(t, files) },
+		"seekable": func() io.Reader { return bytes.NewReader(test.CreateArchive(t, files).Bytes()) },
+	} {
+		t.Run("Last occurrence wins from "+name, func(t *testing.T) {
+			calls := 0
+			root, err := Scan(r(), func(n *SimpleNode) error {
+				calls++
+				return nil
+			})
+			require.Nil(t, err)
+			assert.Equal(t, 6, calls) // root, implicit then explicit app, 3 versions of config.yaml
+
+			app := root.Find("app")
+			assert.False(t, app.IsImplicit())
+			assert.Nil(t, app.GetVersions())
+			require.Equal(t, 1, app.LenChildren())
+
+			config := app.Find("config.yaml")
+			assert.Equal(t, "version: 3", string(config.GetData()))
+			assert.Equal(t, 2, config.GetVersion())
+			versions := config.GetVersions()
+			require.Len(t, versions, 3)
+			for i, v := range versions {
+				assert.Equal(t, i, v.GetVersion())
+				assert.Equal(t, fmt.Sprintf("version: %d", i+1), string(v.GetData()))
+			}
+			assert.Equal(t, config, versions[2])
+
+			require.Nil(t, config.SelectVersion(0))
+			assert.Equal(t, "version: 1", string(config.GetData()))
+			assert.Equal(t, 0, config.GetVersion())
+			assert.Len(t, config.GetVersions(), 3)
+			assert.Error(t, config.SelectVersion(3))
+		})
+	}
+}
+
+func TestDuplicateEntriesReplacingDirectories(t *testing.T) {
+	t.Run("Entry under a replaced directory is replaced", func(t *testing.T) {
+		root, err := Scan(test.CreateArchive(t, []test.File{
+			{Name: "f", Mode: 0644, Body: "file"},
+			{Name: "f/sub/x", Mode: 0644, Body: "version: 1"},
+			{Name: "f/sub/x", Mode: 0644, Body: "version: 2"},
+		}), func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+
+		require.Equal(t, 2, root.LenChildren()) // Parent f is a file, x is kept under the root
+		x := root.GetChildren()[1]
+		assert.Equal(t, "/f/sub/x", x.GetPath())
+		assert.Equal(t, "version: 2", string(x.GetData()))
+		assert.Len(t, x.GetVersions(), 2)
+	})
+
+	t.Run("Directory replaced by a file loses its children", func(t *testing.T) {
+		files := []test.File{
+			{Name: "d/", Mode: 0755, Type: tar.TypeDir},
+			{Name: "d/a", Mode: 0644, Body: "a"},
+			{Name: "d", Mode: 0644, Body: "file"},
+		}
+		root, err := Scan(test.CreateArchive(t, files), func(n *SimpleNode) error { return nil })
+		require.Nil(t, err)
+
+		d := root.Find("d")
+		require.NotNil(t, d)
+		assert.False(t, d.IsDir())
+		assert.False(t, d.IsNested())
+		assert.Equal(t, 0, d.LenChildren())
+		assert.Nil(t, root.Find("d/a"))
+		assert.Equal(t, "file", string(d.GetData()))
+		assert.Len(t, d.GetVersions(), 2)
+
+		list, err := List(test.CreateArchive(t, files))
+		require.Nil(t, err)
+		assert.Equal(t, []string{"/d"}, list)
+	})
+}
+
+func TestGlobalPAXHeader(t *testing.T) {
+	// Like `git archive` output, a global header holds the commit id before entries
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.Nil(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeXGlobalHeader, Name: "pax_global_header", PAXRecords: map[string]string{"comment": "0123abcd"}}))
+	require.Nil(t, tw.WriteHeader(&tar.Header{Name: "repo/README", Mode: 0644, Size: 5}))
+	_, err := tw.Write([]byte("hello"))
+	require.Nil(t, err)
+	require.Nil(t, tw.Close())
+	archive := buf.Bytes()
+
+	for name, r := range map[string]func() io.Reader{
+		"stream":   func() io.Reader { return bytes.NewBuffer(archive) },
+		"seekable": func() io.Reader { return bytes.NewReader(archive) },
+	} {
+		t.Run("Global header is not an entry from "+name, func(t *testing.T) {
+			list, err := List(r())
+			require.Nil(t, err)
+			assert.Equal(t, []string{"/repo/README"}, list)
+		})
+	}
+
+	t.Run("Extract archive with a global header", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.Nil(t, ExtractStream(bytes.NewBuffer(archive), tmpDir, func(n *SimpleNode) bool { return false }))
+		data, err := os.ReadFile(getExtractedPath(tmpDir, "repo/README"))
+		require.Nil(t, err)
+		assert.Equal(t, "hello", string(data))
+		assert.NoFileExists(t, getExtractedPath(tmpDir, "pax_global_header"))
+	})
+}
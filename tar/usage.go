@@ -0,0 +1,105 @@
+package tar
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// DirUsage is the aggregated size of a directory of the archive
+type DirUsage struct {
+	Path  string      `json:"path"`
+	Size  int64       `json:"size"`  // Size is the sum of sizes of all entries under the directory
+	Files int         `json:"files"` // Files is the number of entries, other than directories, under the directory
+	Dirs  []*DirUsage `json:"dirs,omitempty"`
+}
+
+// FileUsage is the size of a file of the archive
+type FileUsage struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// ExtUsage is the aggregated size of files with the same extension
+type ExtUsage struct {
+	Ext   string `json:"ext"` // Ext is the extension with its dot, empty for files without extension
+	Size  int64  `json:"size"`
+	Files int    `json:"files"`
+}
+
+// Usage is the disk usage report of an archive
+type Usage struct {
+	Root       *DirUsage   `json:"root"`
+	Largest    []FileUsage `json:"largest"`    // Largest are the biggest files, largest first
+	Extensions []ExtUsage  `json:"extensions"` // Extensions are sizes by file extension, largest first
+}
+
+// Sizes compute the recursive size of n like `du --apparent-size`: the sum of sizes of entries under a directory,
+// the size of other entries. cb is called with the size of n and of each nested entry, children before their parent.
+// Entries of nested archives (see ScanNested) are not visited, a nested archive file counts for its own size.
+func Sizes[T any](n *Node[T], cb func(nd *Node[T], size int64)) int64 {
+	if !n.IsDir() {
+		cb(n, n.Size())
+		return n.Size()
+	}
+	var size int64
+	for _, child := range n.GetChildren() {
+		size += Sizes(child, cb)
+	}
+	cb(n, size)
+	return size
+}
+
+// DiskUsage aggregate sizes of entries recursively from directory n, like `du --apparent-size` (hardlinks and symlinks count for 0).
+// Sub directories are sorted by size, largest first. top limit the number of largest files and extensions reported (all if top <= 0).
+func DiskUsage[T any](n *Node[T], top int) Usage {
+	var files []FileUsage
+	exts := map[string]*ExtUsage{}
+	dirs := map[*Node[T]]*DirUsage{}
+	Sizes(n, func(nd *Node[T], size int64) {
+		if !nd.IsDir() {
+			files = append(files, FileUsage{Path: nd.GetPath(), Size: size})
+			ext := strings.ToLower(path.Ext(nd.Name()))
+			if exts[ext] == nil {
+				exts[ext] = &ExtUsage{Ext: ext}
+			}
+			exts[ext].Size += size
+			exts[ext].Files++
+			return
+		}
+		du := &DirUsage{Path: nd.GetPath(), Size: size}
+		for _, child := range nd.GetChildren() {
+			if sub, ok := dirs[child]; ok {
+				du.Files += sub.Files
+				du.Dirs = append(du.Dirs, sub)
+			} else {
+				du.Files++
+			}
+		}
+		sort.SliceStable(du.Dirs, func(i, j int) bool { return du.Dirs[i].Size > du.Dirs[j].Size })
+		dirs[nd] = du
+	})
+	usage := Usage{Root: dirs[n]}
+
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Size > files[j].Size })
+	usage.Largest = limit(files, top)
+	for _, e := range exts {
+		usage.Extensions = append(usage.Extensions, *e)
+	}
+	sort.Slice(usage.Extensions, func(i, j int) bool {
+		if usage.Extensions[i].Size != usage.Extensions[j].Size {
+			return usage.Extensions[i].Size > usage.Extensions[j].Size
+		}
+		return usage.Extensions[i].Ext < usage.Extensions[j].Ext
+	})
+	usage.Extensions = limit(usage.Extensions, top)
+	return usage
+}
+
+// limit return the first n elements of s, s if n <= 0
+func limit[E any](s []E, n int) []E {
+	if n > 0 && len(s) > n {
+		return s[:n]
+	}
+	return s
+}
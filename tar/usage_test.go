@@ -0,0 +1,66 @@
+package tar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskUsage(t *testing.T) {
+	root, err := Scan(test.CreateArchive(t, []test.File{
+		{Name: "app/", Mode: 0755},
+		{Name: "app/bin/server", Body: strings.Repeat("x", 500)},
+		{Name: "app/lib/a.so", Body: strings.Repeat("x", 200)},
+		{Name: "app/lib/b.so", Body: strings.Repeat("x", 100)},
+		{Name: "app/README", Body: strings.Repeat("x", 10)},
+		{Name: "etc/app.CONF", Body: strings.Repeat("x", 50)},
+		{Name: "etc/link", Type: '2', Linkname: "app.CONF"},
+	}), func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+
+	usage := DiskUsage(root, 2)
+	assert.Equal(t, int64(860), usage.Root.Size)
+	assert.Equal(t, 6, usage.Root.Files)
+
+	require.Len(t, usage.Root.Dirs, 2)
+	app := usage.Root.Dirs[0]
+	assert.Equal(t, "/app", app.Path)
+	assert.Equal(t, int64(810), app.Size)
+	assert.Equal(t, 4, app.Files)
+	require.Len(t, app.Dirs, 2)
+	assert.Equal(t, "/app/bin", app.Dirs[0].Path, "directories are sorted by size")
+	assert.Equal(t, DirUsage{Path: "/app/lib", Size: 300, Files: 2}, *app.Dirs[1])
+	assert.Equal(t, "/etc", usage.Root.Dirs[1].Path)
+
+	assert.Equal(t, []FileUsage{{Path: "/app/bin/server", Size: 500}, {Path: "/app/lib/a.so", Size: 200}}, usage.Largest)
+	assert.Equal(t, []ExtUsage{{Ext: "", Size: 510, Files: 3}, {Ext: ".so", Size: 300, Files: 2}}, usage.Extensions)
+
+	all := DiskUsage(root, 0)
+	assert.Len(t, all.Largest, 6)
+	assert.Equal(t, ExtUsage{Ext: ".conf", Size: 50, Files: 1}, all.Extensions[2])
+}
+
+func TestSizes(t *testing.T) {
+	inner := test.CreateArchive(t, []test.File{{Name: "big", Body: strings.Repeat("x", 1000)}})
+	root, err := Scan(test.CreateArchive(t, []test.File{
+		{Name: "app/a", Body: strings.Repeat("x", 10)},
+		{Name: "app/b", Body: strings.Repeat("x", 20)},
+		{Name: "inner.tar", Body: inner.String()},
+	}), func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+	nested := root.Find("inner.tar")
+	require.Nil(t, nested.ScanNested(func(n *SimpleNode) error { return nil }))
+
+	sizes := map[string]int64{}
+	var order []string
+	total := Sizes(root, func(n *SimpleNode, size int64) {
+		sizes[n.GetPath()] = size
+		order = append(order, n.GetPath())
+	})
+	assert.Equal(t, int64(30)+nested.Size(), total, "nested archive counts for its own size")
+	assert.Equal(t, map[string]int64{"/": total, "/app": 30, "/app/a": 10, "/app/b": 20, "/inner.tar": nested.Size()}, sizes)
+	assert.Equal(t, []string{"/app/a", "/app/b", "/app", "/inner.tar", "/"}, order, "children are visited before their parent")
+}
@@ -0,0 +1,187 @@
+package tar
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+const (
+	Missing ChangeType = "missing" // Entry of the archive not found on disk
+	Extra   ChangeType = "extra"   // File on disk not found in the archive
+)
+
+// Checks list what can be compared between archive entries and files on disk, besides existence and type
+var Checks = []string{"size", "mode", "mtime", "linkname", "content", "extra"}
+
+// Mismatch is a difference between an archive entry and the file system
+type Mismatch[T any] struct {
+	Path    string     // Path of the entry relative to the archive root
+	Type    ChangeType // Missing, Modified or Extra
+	Reasons []string   // What differs on a modified entry: type, size, mode, mtime, linkname or content
+	Node    *Node[T]   // Entry in the archive, nil for extra files
+}
+
+// verifier compare archive entries with files under dir
+type verifier struct {
+	dir     string
+	ignored []string
+}
+
+// VerifyOption configure how entries are compared with the file system
+type VerifyOption func(*verifier)
+
+// WithIgnoredChecks don't compare given checks (see Checks), eg: "mode" and "mtime" when extracted without preserving them
+func WithIgnoredChecks(checks ...string) VerifyOption {
+	return func(v *verifier) { v.ignored = append(v.ignored, checks...) }
+}
+
+// ParseCheck return an error if check is not one of Checks
+func ParseCheck(check string) error {
+	if !slices.Contains(Checks, check) {
+		return fmt.Errorf("unknown check %q, expected one of %v", check, Checks)
+	}
+	return nil
+}
+
+func (v *verifier) enabled(check string) bool { return !slices.Contains(v.ignored, check) }
+
+// fileHash return the sha256 of file content
+func fileHash(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// compareFile return what differs between an archive entry and the file at target.
+// Only existence and type of implicit directories are compared as they are not stored in the archive.
+func compareFile[T any](v *verifier, n *Node[T], target string, fi fs.FileInfo) ([]string, error) {
+	if n.Mode().Type() != fi.Mode().Type() {
+		return []string{"type"}, nil
+	}
+	if n.IsImplicit() {
+		return nil, nil
+	}
+	h := n.header
+	var reasons []string
+	if v.enabled("size") && n.IsRegular() && h.Size != fi.Size() {
+		reasons = append(reasons, "size")
+	}
+	isSymlink := h.Typeflag == tar.TypeSymlink // Symlinks have no mode and their times are not restored
+	if v.enabled("mode") && !isSymlink && n.Mode().Perm() != fi.Mode().Perm() {
+		reasons = append(reasons, "mode")
+	}
+	if v.enabled("mtime") && !isSymlink && h.ModTime.Unix() != fi.ModTime().Unix() {
+		reasons = append(reasons, "mtime")
+	}
+	if v.enabled("linkname") {
+		switch h.Typeflag {
+		case tar.TypeSymlink:
+			link, err := os.Readlink(target)
+			if err != nil {
+				return nil, fmt.Errorf("error on read symlink %s: %s", target, err)
+			}
+			if link != h.Linkname {
+				reasons = append(reasons, "linkname")
+			}
+		case tar.TypeLink:
+			linked, err := os.Lstat(filepath.Join(v.dir, filepath.FromSlash(path.Join("/", h.Linkname))))
+			if err != nil || !os.SameFile(fi, linked) {
+				reasons = append(reasons, "linkname")
+			}
+		}
+	}
+	if v.enabled("content") && n.IsRegular() && h.Size == fi.Size() {
+		expected, err := n.Hash()
+		if err != nil {
+			return nil, fmt.Errorf("error on read %s: %s", n.GetPath(), err)
+		}
+		actual, err := fileHash(target)
+		if err != nil {
+			return nil, fmt.Errorf("error on read %s: %s", target, err)
+		}
+		if !bytes.Equal(expected, actual) {
+			reasons = append(reasons, "content")
+		}
+	}
+	return reasons, nil
+}
+
+// Verify compare entries of the tree with files under dir and return mismatches sorted by path.
+// Entries are checked for existence, type, size, mode, modification time, link target and content (sha256 hash),
+// files under dir which are not in the archive are reported as extra.
+func Verify[T any](root *Node[T], dir string, opts ...VerifyOption) ([]Mismatch[T], error) {
+	v := &verifier{dir: dir}
+	for _, opt := range opts {
+		opt(v)
+	}
+	nodes := relativeNodes(root)
+	paths := make([]string, 0, len(nodes))
+	for p := range nodes {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var mismatches []Mismatch[T]
+	for _, p := range paths {
+		n := nodes[p]
+		target := filepath.Join(dir, filepath.FromSlash(p))
+		fi, err := os.Lstat(target)
+		if os.IsNotExist(err) {
+			mismatches = append(mismatches, Mismatch[T]{Path: p, Type: Missing, Node: n})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error on stat %s: %s", target, err)
+		}
+		reasons, err := compareFile(v, n, target, fi)
+		if err != nil {
+			return nil, err
+		}
+		if len(reasons) > 0 {
+			mismatches = append(mismatches, Mismatch[T]{Path: p, Type: Modified, Reasons: reasons, Node: n})
+		}
+	}
+	if !v.enabled("extra") {
+		return mismatches, nil
+	}
+
+	err := filepath.WalkDir(dir, func(target string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, target)
+		if err != nil || rel == "." {
+			return err
+		}
+		p := "/" + filepath.ToSlash(rel)
+		if _, ok := nodes[p]; ok {
+			return nil
+		}
+		mismatches = append(mismatches, Mismatch[T]{Path: p, Type: Extra})
+		if d.IsDir() { // Children of an extra directory are not listed
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error on walk %s: %s", dir, err)
+	}
+	sort.SliceStable(mismatches, func(i, j int) bool { return mismatches[i].Path < mismatches[j].Path })
+	return mismatches, nil
+}
@@ -0,0 +1,84 @@
+package tar
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/franciscolkdo/guntar/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	mtime := time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC)
+	files := []test.File{
+		{Name: "./app/", Mode: 0755, Type: tar.TypeDir, ModTime: mtime},
+		{Name: "./app/conf.yaml", Mode: 0644, Body: "port: 80", ModTime: mtime},
+		{Name: "./app/run.sh", Mode: 0755, Body: "echo", ModTime: mtime},
+		{Name: "./app/removed.txt", Mode: 0644, Body: "removed", ModTime: mtime},
+		{Name: "./app/current", Mode: 0777, Type: tar.TypeSymlink, Linkname: "conf.yaml", ModTime: mtime},
+		{Name: "./app/hardlink", Mode: 0644, Type: tar.TypeLink, Linkname: "./app/conf.yaml", ModTime: mtime},
+		{Name: "./lib/libc.so", Mode: 0644, Body: "elf", ModTime: mtime},
+	}
+	root, err := Scan(test.CreateArchive(t, files), func(n *SimpleNode) error { return nil })
+	require.Nil(t, err)
+	tmpDir := t.TempDir()
+	require.Nil(t, Extract(root, tmpDir, func(n *SimpleNode) bool { return false }, WithPreservePermissions(), WithPreserveTimes()))
+	dir := filepath.Join(tmpDir, ExtractFolder)
+
+	t.Run("Extracted archive", func(t *testing.T) {
+		mismatches, err := Verify(root, dir)
+		require.Nil(t, err)
+		assert.Empty(t, mismatches)
+	})
+
+	require.Nil(t, os.Remove(filepath.Join(dir, "app/conf.yaml"))) // Hardlink keeps the previous file
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "app/conf.yaml"), []byte("port: 81"), 0644))
+	require.Nil(t, os.Chmod(filepath.Join(dir, "app/run.sh"), 0700))
+	require.Nil(t, os.Chtimes(filepath.Join(dir, "app/run.sh"), mtime, mtime))
+	require.Nil(t, os.Remove(filepath.Join(dir, "app/removed.txt")))
+	require.Nil(t, os.Remove(filepath.Join(dir, "app/current")))
+	require.Nil(t, os.Symlink("run.sh", filepath.Join(dir, "app/current")))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "app/extra.txt"), []byte("extra"), 0644))
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "cache/tmp"), 0755))
+
+	type result struct {
+		Path    string
+		Type    ChangeType
+		Reasons []string
+	}
+	verify := func(t *testing.T, opts ...VerifyOption) []result {
+		mismatches, err := Verify(root, dir, opts...)
+		require.Nil(t, err)
+		var results []result
+		for _, m := range mismatches {
+			results = append(results, result{Path: m.Path, Type: m.Type, Reasons: m.Reasons})
+		}
+		return results
+	}
+
+	t.Run("Modified directory", func(t *testing.T) {
+		assert.Equal(t, []result{
+			{Path: "/app", Type: Modified, Reasons: []string{"mtime"}},
+			{Path: "/app/conf.yaml", Type: Modified, Reasons: []string{"mtime", "content"}},
+			{Path: "/app/current", Type: Modified, Reasons: []string{"linkname"}},
+			{Path: "/app/extra.txt", Type: Extra},
+			{Path: "/app/hardlink", Type: Modified, Reasons: []string{"linkname"}},
+			{Path: "/app/removed.txt", Type: Missing},
+			{Path: "/app/run.sh", Type: Modified, Reasons: []string{"mode"}},
+			{Path: "/cache", Type: Extra},
+		}, verify(t))
+	})
+
+	t.Run("Ignored checks", func(t *testing.T) {
+		assert.Equal(t, []result{
+			{Path: "/app/conf.yaml", Type: Modified, Reasons: []string{"content"}},
+			{Path: "/app/current", Type: Modified, Reasons: []string{"linkname"}},
+			{Path: "/app/hardlink", Type: Modified, Reasons: []string{"linkname"}},
+			{Path: "/app/removed.txt", Type: Missing},
+		}, verify(t, WithIgnoredChecks("mode", "mtime", "extra")))
+	})
+}
@@ -0,0 +1,106 @@
+package tar
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// zipMagics are the signatures of a zip archive starting with a file or of an empty zip archive
+var zipMagics = [][]byte{[]byte("PK\x03\x04"), []byte("PK\x05\x06")}
+
+// isZip return true if head is the beginning of a zip archive (including jar, apk, etc...)
+func isZip(head []byte) bool {
+	for _, magic := range zipMagics {
+		if bytes.HasPrefix(head, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// zipReader read entries of a zip archive, contents are always available lazily
+type zipReader struct {
+	files   []*zip.File
+	current int
+	rc      io.ReadCloser // rc is the content of current entry, opened on first read
+}
+
+func newZipReader(r io.ReaderAt, size int64) (*zipReader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("on reading zip archive: %s", err)
+	}
+	return &zipReader{files: zr.File, current: -1}, nil
+}
+
+// zipHeader describe a zip file with a tar header, content of symlinks is their target
+func zipHeader(f *zip.File) (*tar.Header, error) {
+	fi := f.FileInfo()
+	header := &tar.Header{
+		Name:     f.Name,
+		Mode:     int64(fi.Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)),
+		Size:     int64(f.UncompressedSize64),
+		ModTime:  f.Modified,
+		Typeflag: tar.TypeReg,
+	}
+	switch {
+	case fi.IsDir():
+		header.Typeflag = tar.TypeDir
+		header.Size = 0
+	case fi.Mode()&fs.ModeSymlink != 0:
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		target, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+		header.Typeflag = tar.TypeSymlink
+		header.Linkname = string(target)
+		header.Size = 0
+	}
+	return header, nil
+}
+
+func (z *zipReader) Next() (*tar.Header, error) {
+	if z.rc != nil {
+		z.rc.Close()
+		z.rc = nil
+	}
+	z.current++
+	if z.current >= len(z.files) {
+		return nil, io.EOF
+	}
+	header, err := zipHeader(z.files[z.current])
+	if err != nil {
+		return nil, fmt.Errorf("on reading zip entry %s: %s", z.files[z.current].Name, err)
+	}
+	return header, nil
+}
+
+func (z *zipReader) Read(p []byte) (int, error) {
+	if z.current < 0 || z.current >= len(z.files) {
+		return 0, io.EOF
+	}
+	if z.rc == nil {
+		var err error
+		if z.rc, err = z.files[z.current].Open(); err != nil {
+			return 0, err
+		}
+	}
+	return z.rc.Read(p)
+}
+
+func (z *zipReader) Lazy() func() (io.ReadCloser, error) {
+	f := z.files[z.current]
+	if f.FileInfo().IsDir() || f.Mode()&fs.ModeSymlink != 0 {
+		return nil
+	}
+	return f.Open
+}
@@ -0,0 +1,81 @@
+package tar
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createZip(t *testing.T) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	mtime := time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC)
+	entries := []struct {
+		name string
+		mode fs.FileMode
+		body string
+	}{
+		{name: "META-INF/", mode: fs.ModeDir | 0755},
+		{name: "META-INF/MANIFEST.MF", mode: 0644, body: "Manifest-Version: 1.0\n"},
+		{name: "com/example/App.class", mode: 0644, body: "\xca\xfe\xba\xbe"},
+		{name: "latest", mode: fs.ModeSymlink | 0777, body: "META-INF/MANIFEST.MF"},
+	}
+	for _, e := range entries {
+		fh := &zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: mtime}
+		fh.SetMode(e.mode)
+		w, err := zw.CreateHeader(fh)
+		require.Nil(t, err)
+		_, err = w.Write([]byte(e.body))
+		require.Nil(t, err)
+	}
+	require.Nil(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestScanZip(t *testing.T) {
+	archive := createZip(t)
+	readers := map[string]func() io.Reader{
+		"Seekable":     func() io.Reader { return bytes.NewReader(archive) },
+		"Not seekable": func() io.Reader { return io.MultiReader(bytes.NewReader(archive)) },
+	}
+	for name, reader := range readers {
+		t.Run(name, func(t *testing.T) {
+			root, err := Scan(reader(), func(n *SimpleNode) error { return nil })
+			require.Nil(t, err)
+			manifest := root.Find("META-INF/MANIFEST.MF")
+			require.NotNil(t, manifest)
+			assert.Equal(t, "Manifest-Version: 1.0\n", string(manifest.GetData()))
+			assert.Equal(t, fs.FileMode(0644), manifest.Mode())
+			assert.Equal(t, time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC), manifest.ModTime().UTC())
+			assert.True(t, root.Find("com").IsImplicit())
+			assert.Equal(t, "META-INF/MANIFEST.MF", root.Find("latest").GetHeader().Linkname)
+		})
+	}
+
+	t.Run("List", func(t *testing.T) {
+		res, err := List(bytes.NewReader(archive))
+		require.Nil(t, err)
+		assert.Equal(t, []string{"/META-INF", "/META-INF/MANIFEST.MF", "/com/example/App.class", "/latest"}, res)
+	})
+
+	t.Run("Extract stream", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.Nil(t, ExtractStream(io.MultiReader(bytes.NewReader(archive)), tmpDir, func(n *SimpleNode) bool { return false }))
+		data, err := os.ReadFile(getExtractedPath(tmpDir, "latest"))
+		require.Nil(t, err)
+		assert.Equal(t, "Manifest-Version: 1.0\n", string(data))
+		assert.FileExists(t, getExtractedPath(tmpDir, "com/example/App.class"))
+	})
+
+	t.Run("Corrupted zip", func(t *testing.T) {
+		_, err := Scan(bytes.NewReader(archive[:100]), func(n *SimpleNode) error { return nil })
+		assert.ErrorContains(t, err, "on reading zip archive")
+	})
+}
@@ -2,11 +2,14 @@ package terminal
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
 	"github.com/franciscolkdo/guntar/tar"
 )
 
@@ -18,6 +21,7 @@ const cursor = ">"
 // ListerModel represents a file picker.
 type ListerModel struct {
 	exportPath      string
+	extractOptions  []tar.ExtractOption
 	KeyMap          KeyMap
 	currentNode     *listerNode
 	ShowPermissions bool
@@ -30,13 +34,17 @@ type ListerModel struct {
 	minStack        stack
 	Height          int
 	flatten         bool
+	usage           bool // usage show recursive sizes of children sorted by size, like ncdu
 	enterFileView   setViewTypeMsg
 }
 
 // NewLister return a Node lister with default styling and key bindings.
-func NewLister(n *listerNode, exportPath string) ListerModel {
+// Recursive sizes of directories are computed once here.
+func NewLister(n *listerNode, exportPath string, opts ...tar.ExtractOption) ListerModel {
+	cacheSizes(n)
 	return ListerModel{
 		exportPath:      exportPath,
+		extractOptions:  opts,
 		selected:        0,
 		currentNode:     n,
 		ShowPermissions: true,
@@ -97,7 +105,31 @@ func readDirNode(n *listerNode) tea.Cmd {
 }
 
 func (m ListerModel) GetSelectedFile() *listerNode {
-	return m.currentNode.GetChildren()[m.selected]
+	return m.children()[m.selected]
+}
+
+// children return children of current node as displayed, sorted by size in disk usage mode
+func (m ListerModel) children() []*listerNode {
+	if !m.usage {
+		return m.currentNode.GetChildren()
+	}
+	children := slices.Clone(m.currentNode.GetChildren())
+	sort.SliceStable(children, func(i, j int) bool { return children[i].Spec.size > children[j].Spec.size })
+	return children
+}
+
+// toggleUsage switch between disk usage and archive order, the selected node is kept
+func (m *ListerModel) toggleUsage() {
+	var selected *listerNode
+	if m.currentNode.LenChildren() > 0 {
+		selected = m.GetSelectedFile()
+	}
+	m.usage = !m.usage
+	m.selected = max(slices.Index(m.children(), selected), 0)
+	if m.selected < m.min || m.selected > m.max {
+		m.min = m.selected
+		m.max = m.min + m.Height - 1
+	}
 }
 
 func (m *ListerModel) up() {
@@ -127,20 +159,53 @@ func (m ListerModel) open() (ListerModel, tea.Cmd) {
 		return m, nil
 	}
 
-	f := m.currentNode.GetChildren()[m.selected]
+	f := m.GetSelectedFile()
 
-	if f.IsDir() {
+	if f.IsDir() || f.IsNested() {
 		m.pushView(m.selected, m.min, m.max)
 		m.selected = 0
 		m.min = 0
 		m.max = m.Height - 1
 		return m, readDirNode(f)
 	} else if f.Mode().IsRegular() {
-		return m, setView(m.enterFileView)
+		// Archive files are opened as directories once scanned, other files are read
+		return m, scanNested(f)
 	}
 	return m, nil
 }
 
+// nestedMsg is the result of the scan of an archive file (see scanNested)
+type nestedMsg struct {
+	node    *listerNode
+	entries []*listerNode
+	err     error
+}
+
+// scanNested scan the archive file n in background, its entries are attached to the tree on nestedMsg
+func scanNested(n *listerNode) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := n.NestedEntries(OnNewNode)
+		return nestedMsg{node: n, entries: entries, err: err}
+	}
+}
+
+// openNested attach entries of a scanned archive file and open it, the file is read if it's not an archive.
+// Nothing is done if the file is not selected anymore.
+func (m ListerModel) openNested(msg nestedMsg) (ListerModel, tea.Cmd) {
+	if m.currentNode.LenChildren() == 0 || m.GetSelectedFile() != msg.node {
+		return m, nil
+	}
+	if msg.err != nil {
+		return m, setView(m.enterFileView)
+	}
+	msg.node.AddNested(msg.entries)
+	msg.node.Spec.style = defaultStyle.Directory
+	for _, entry := range msg.entries { // Nested archive keeps its own size
+		cacheSizes(entry)
+	}
+	return m.open()
+}
+
 func (m ListerModel) back() (ListerModel, tea.Cmd) {
 	if m.selectedStack.Length() > 0 {
 		m.selected, m.min, m.max = m.popView()
@@ -178,13 +243,26 @@ func setSelectionParentNode(node *listerNode) {
 
 func (m *ListerModel) extract(node *listerNode) tea.Cmd {
 	if err := tar.Extract(node, m.exportPath, func(n *listerNode) bool {
-		return n.Spec.selectionStatus == NotSelected
-	}); err != nil {
+		// A nested archive file is only partially selected when some of its entries are selected
+		return n.Spec.selectionStatus == NotSelected || (n.IsNested() && n.Spec.selectionStatus == PartialSelected)
+	}, m.extractOptions...); err != nil {
 		return func() tea.Msg { return errMsg(err) }
 	}
 	return nil
 }
 
+// nextVersion use the next occurrence in the archive of the selected node, if its path appears several times
+func (m *ListerModel) nextVersion() {
+	if m.currentNode.LenChildren() == 0 {
+		return
+	}
+	sf := m.GetSelectedFile()
+	if versions := sf.GetVersions(); versions != nil {
+		_ = sf.SelectVersion((sf.GetVersion() + 1) % len(versions)) // Version always exists
+		updateSize(sf)
+	}
+}
+
 func (m *ListerModel) SetSize(msg tea.WindowSizeMsg) {
 	m.Height = msg.Height - marginBottom
 	m.max = m.Height - 1
@@ -201,6 +279,8 @@ func (m ListerModel) Update(msg tea.Msg) (ListerModel, tea.Cmd) {
 	case DirMsg:
 		m.currentNode = msg.node
 		m.max = max(m.max, m.Height-1)
+	case nestedMsg:
+		return m.openNested(msg)
 	case tea.WindowSizeMsg:
 		m.SetSize(msg)
 	case tea.KeyMsg:
@@ -248,7 +328,7 @@ func (m ListerModel) Update(msg tea.Msg) (ListerModel, tea.Cmd) {
 		case key.Matches(msg, m.KeyMap.Open):
 			return m.open()
 		case key.Matches(msg, m.KeyMap.Select):
-			sf := m.currentNode.GetChildren()[m.selected]
+			sf := m.GetSelectedFile()
 			if getSelectionStatus(*sf) == NotSelected {
 				setSelectionNode(sf, Selected)
 			} else {
@@ -256,6 +336,10 @@ func (m ListerModel) Update(msg tea.Msg) (ListerModel, tea.Cmd) {
 			}
 			setSelectionParentNode(sf)
 
+		case key.Matches(msg, m.KeyMap.Version):
+			m.nextVersion()
+		case key.Matches(msg, m.KeyMap.Usage):
+			m.toggleUsage()
 		case key.Matches(msg, m.KeyMap.Extract):
 			return m, m.extract(m.currentNode.GetRoot())
 		}
@@ -275,13 +359,21 @@ func (m ListerModel) Update(msg tea.Msg) (ListerModel, tea.Cmd) {
 // View returns the view of the file picker.
 func (m ListerModel) View() string {
 	var s strings.Builder
-	s.WriteString(fmt.Sprintf("[%s]\n", m.currentNode.GetPath()))
+	var total int64 // Size of nested archives differs from the size of their entries when compressed
+	for _, n := range m.currentNode.GetChildren() {
+		total += n.Spec.size
+	}
+	if m.usage {
+		s.WriteString(fmt.Sprintf("[%s] %s (disk usage)\n", m.currentNode.GetPath(), strings.Replace(humanize.Bytes(uint64(total)), " ", "", 1)))
+	} else {
+		s.WriteString(fmt.Sprintf("[%s]\n", m.currentNode.GetPath()))
+	}
 
 	if m.currentNode.LenChildren() == 0 {
 		return defaultStyle.EmptyDirectory.Height(m.Height).MaxHeight(m.Height).String()
 	}
 
-	for i, n := range m.currentNode.GetChildren() {
+	for i, n := range m.children() {
 		if i < m.min || i > m.max {
 			continue
 		}
@@ -295,7 +387,11 @@ func (m ListerModel) View() string {
 		}
 
 		// Render line
-		s.WriteString(style.Render(prefix, formatNode(*n)))
+		line := formatNode(*n)
+		if m.usage {
+			line = formatUsage(*n, total)
+		}
+		s.WriteString(style.Render(prefix, line))
 		s.WriteRune('\n')
 	}
 
@@ -4,6 +4,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -54,6 +55,7 @@ func TestNewLister(t *testing.T) {
 	t.Run("Ask enterFileView on key enter (file selected)", func(t *testing.T) {
 		var cmd tea.Cmd
 		l, cmd = l.Update(tea.KeyMsg{Type: tea.KeyEnter}) // Run open function on keyEnter
+		l, cmd = l.Update(cmd())                          // File is not an archive
 		assert.Equal(t, l.enterFileView, cmd().(setViewTypeMsg))
 	})
 
@@ -195,3 +197,147 @@ func TestListerSelection(t *testing.T) {
 		}
 	})
 }
+
+func TestListerVersions(t *testing.T) {
+	files := []test.File{
+		{Name: "./config.yaml", Mode: 0600, Body: "version: 1"},
+		{Name: "./config.yaml", Mode: 0600, Body: "version: 2"},
+	}
+	root, err := tar.Scan(test.CreateArchive(t, files), OnNewNode)
+	require.Nil(t, err)
+	l := NewLister(root, "")
+	l.SetSize(tea.WindowSizeMsg{Height: 10})
+	config := l.GetSelectedFile()
+
+	t.Run("Show last version", func(t *testing.T) {
+		assert.Equal(t, "version: 2", string(config.GetData()))
+		assert.Contains(t, l.View(), "(version 2/2)")
+	})
+
+	t.Run("Cycle versions on version key", func(t *testing.T) {
+		var cmd tea.Cmd
+		l, cmd = l.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+		assert.Nil(t, cmd)
+		assert.Equal(t, "version: 1", string(config.GetData()))
+		assert.Contains(t, l.View(), "(version 1/2)")
+		l, _ = l.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+		assert.Equal(t, "version: 2", string(config.GetData()))
+	})
+}
+
+func TestListerNestedArchive(t *testing.T) {
+	inner := test.CreateArchive(t, []test.File{
+		{Name: "./etc/", Mode: 0755, Body: ""},
+		{Name: "./etc/app.conf", Mode: 0600, Body: "port: 80"},
+		{Name: "./etc/other.conf", Mode: 0600, Body: "other"},
+	})
+	files := []test.File{
+		{Name: "./app.tar", Mode: 0600, Body: inner.String()},
+		{Name: "./readme.txt", Mode: 0600, Body: "hello"},
+	}
+	root, err := tar.Scan(test.CreateArchive(t, files), OnNewNode)
+	require.Nil(t, err)
+	l := NewLister(root, "")
+	l.SetSize(tea.WindowSizeMsg{Height: 10})
+
+	t.Run("Open archive file as directory", func(t *testing.T) {
+		var cmd tea.Cmd
+		l, cmd = l.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		msg := cmd()
+		require.IsType(t, nestedMsg{}, msg) // Archive is scanned in background
+		assert.False(t, root.Find("app.tar").IsNested())
+		l, cmd = l.Update(msg)
+		assert.IsType(t, DirMsg{}, cmd())
+		l, _ = l.Update(cmd())
+		l, cmd = l.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		l, _ = l.Update(cmd())
+		assert.Equal(t, "/app.tar!/etc", l.currentNode.GetPath())
+		assert.Equal(t, int64(13), l.currentNode.Spec.size, "sizes of nested entries are cached")
+		assert.Contains(t, l.View(), "[/app.tar!/etc]")
+	})
+
+	t.Run("Extract nested selection", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		l.exportPath = tmpDir
+		l, _ = l.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}}) // Select app.conf
+		cmd := l.extract(l.currentNode.GetRoot())
+		assert.Nil(t, cmd)
+		assert.FileExists(t, filepath.Join(tmpDir, tar.ExtractFolder, "app.tar!/etc/app.conf"))
+		assert.NoFileExists(t, filepath.Join(tmpDir, tar.ExtractFolder, "app.tar!/etc/other.conf"))
+		assert.NoFileExists(t, filepath.Join(tmpDir, tar.ExtractFolder, "app.tar"))
+	})
+
+	t.Run("Read other files", func(t *testing.T) {
+		var cmd tea.Cmd
+		for i := 0; i < 2; i++ { // Back to root
+			l, cmd = l.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+			l, _ = l.Update(cmd())
+		}
+		assert.True(t, l.currentNode.IsRoot())
+		l.down()
+		l, cmd = l.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		l, cmd = l.Update(cmd()) // readme.txt is not scanned as an archive
+		assert.Equal(t, l.enterFileView, cmd().(setViewTypeMsg))
+	})
+
+	t.Run("Ignore scan of a file not selected anymore", func(t *testing.T) {
+		cmd := scanNested(root.Find("readme.txt"))
+		l.up()
+		var next tea.Cmd
+		l, next = l.Update(cmd())
+		assert.Nil(t, next)
+	})
+}
+
+func TestListerDiskUsage(t *testing.T) {
+	files := []test.File{
+		{Name: "./small.txt", Mode: 0600, Body: strings.Repeat("x", 100)},
+		{Name: "./lib/", Mode: 0755, Body: ""},
+		{Name: "./lib/a.so", Mode: 0600, Body: strings.Repeat("x", 600)},
+		{Name: "./lib/b.so", Mode: 0600, Body: strings.Repeat("x", 300)},
+		{Name: "./config.yaml", Mode: 0600, Body: "version: 1"},
+		{Name: "./config.yaml", Mode: 0600, Body: "version: 10"},
+	}
+	root, err := tar.Scan(test.CreateArchive(t, files), OnNewNode)
+	require.Nil(t, err)
+	l := NewLister(root, "")
+	l.SetSize(tea.WindowSizeMsg{Height: 10})
+
+	t.Run("Sizes are cached after scan", func(t *testing.T) {
+		assert.Equal(t, int64(1011), root.Spec.size)
+		assert.Equal(t, int64(900), root.Find("lib").Spec.size)
+		assert.Contains(t, l.View(), "900B")
+	})
+
+	t.Run("Sort by size on usage key", func(t *testing.T) {
+		assert.Equal(t, "/small.txt", l.GetSelectedFile().GetPath())
+		l, _ = l.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+		assert.Equal(t, "/small.txt", l.GetSelectedFile().GetPath(), "selected node is kept")
+		assert.Equal(t, 1, l.selected)
+		assert.Equal(t, []string{"/lib", "/small.txt", "/config.yaml"}, []string{
+			l.children()[0].GetPath(), l.children()[1].GetPath(), l.children()[2].GetPath(),
+		})
+		view := l.View()
+		assert.Contains(t, view, "[/] 1.0kB (disk usage)")
+		assert.Contains(t, view, " 89.0% [#########")
+	})
+
+	t.Run("Drill down into largest directory", func(t *testing.T) {
+		var cmd tea.Cmd
+		l, _ = l.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+		l, cmd = l.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		l, _ = l.Update(cmd())
+		assert.Equal(t, "/lib", l.currentNode.GetPath())
+		assert.Equal(t, "/lib/a.so", l.GetSelectedFile().GetPath())
+		assert.Contains(t, l.View(), " 66.7% [#######   ]")
+		l, cmd = l.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+		l, _ = l.Update(cmd())
+	})
+
+	t.Run("Version update parents size", func(t *testing.T) {
+		l, _ = l.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'G'}})
+		assert.Equal(t, "/config.yaml", l.GetSelectedFile().GetPath())
+		l, _ = l.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+		assert.Equal(t, int64(1010), root.Spec.size)
+	})
+}
@@ -14,6 +14,8 @@ type KeyMap struct {
 	Open     key.Binding
 	Select   key.Binding
 	Extract  key.Binding
+	Version  key.Binding
+	Usage    key.Binding
 	Quit     key.Binding
 }
 
@@ -30,6 +32,8 @@ func DefaultKeyMap() KeyMap {
 		Open:     key.NewBinding(key.WithKeys("l", "right", "enter"), key.WithHelp("l", "open")),
 		Select:   key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "select")),
 		Extract:  key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "extract")),
+		Version:  key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "next version")),
+		Usage:    key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "disk usage")),
 		Quit:     key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
 	}
 }
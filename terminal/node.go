@@ -21,6 +21,7 @@ const (
 type listerData struct {
 	selectionStatus SelectedState
 	style           lipgloss.Style
+	size            int64 // size is the recursive size of directories, size of other nodes (see cacheSizes)
 }
 
 // listerNode is an alias to Node[listerData]
@@ -40,10 +41,50 @@ func OnNewNode(n *listerNode) error {
 func formatNode(n listerNode) string {
 	// Add file mode
 	line := " " + defaultStyle.Permission.Render(n.Mode().String())
-	// Add file size
-	line += fmt.Sprintf("%"+strconv.Itoa(defaultStyle.FileSize.GetWidth())+"s", strings.Replace(humanize.Bytes(uint64(n.Size())), " ", "", 1))
+	// Add file size, recursive size for directories
+	line += fmt.Sprintf("%"+strconv.Itoa(defaultStyle.FileSize.GetWidth())+"s", strings.Replace(humanize.Bytes(uint64(n.Spec.size)), " ", "", 1))
 	// Add file name
 	line += " " + n.Spec.style.Render(n.Name())
+	// Add version if path appears several times in archive
+	if versions := n.GetVersions(); versions != nil {
+		line += defaultStyle.Permission.Render(fmt.Sprintf(" (version %d/%d)", n.GetVersion()+1, len(versions)))
+	}
+	// Add layer which wrote the file in image mode
+	if layer := n.GetLayer(); layer != "" {
+		line += defaultStyle.Permission.Render(" [" + layer + "]")
+	}
+	return line
+}
+
+// cacheSizes compute and store the recursive size of n and its children (see tar.Sizes)
+func cacheSizes(n *listerNode) {
+	tar.Sizes(n, func(nd *listerNode, size int64) { nd.Spec.size = size })
+}
+
+// updateSize refresh the cached size of a file (eg: when its version changed) and of its parents
+func updateSize(n *listerNode) {
+	if n.IsDir() {
+		return
+	}
+	delta := n.Size() - n.Spec.size
+	n.Spec.size += delta
+	for p := n; !p.IsRoot(); {
+		p = p.GetParent()
+		p.Spec.size += delta
+	}
+}
+
+// formatUsage return the disk usage line of n: recursive size, percentage of parent size with a bar and name
+func formatUsage(n listerNode, parentSize int64) string {
+	ratio := 0.0
+	if parentSize > 0 {
+		ratio = float64(n.Spec.size) / float64(parentSize)
+	}
+	filled := int(ratio*usageBarWidth + 0.5)
+	line := fmt.Sprintf("%"+strconv.Itoa(defaultStyle.FileSize.GetWidth())+"s", strings.Replace(humanize.Bytes(uint64(n.Spec.size)), " ", "", 1))
+	line += defaultStyle.Permission.Render(fmt.Sprintf(" %5.1f%% ", ratio*100))
+	line += "[" + defaultStyle.UsageBar.Render(strings.Repeat("#", filled)) + strings.Repeat(" ", usageBarWidth-filled) + "]"
+	line += " " + n.Spec.style.Render(n.Name())
 	return line
 }
 
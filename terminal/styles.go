@@ -5,6 +5,7 @@ import "github.com/charmbracelet/lipgloss"
 const (
 	fileSizeWidth = 7
 	paddingLeft   = 2
+	usageBarWidth = 10
 )
 
 // Styles defines the possible customizations for styles in the file picker.
@@ -19,6 +20,7 @@ type Styles struct {
 	PartialSelectedStatus lipgloss.Style
 	FileSize              lipgloss.Style
 	EmptyDirectory        lipgloss.Style
+	UsageBar              lipgloss.Style
 }
 
 // DefaultStyles defines the default styling for the file picker.
@@ -40,6 +42,7 @@ func DefaultStylesWithRenderer(r *lipgloss.Renderer) Styles {
 		PartialSelectedStatus: r.NewStyle().Foreground(lipgloss.Color("172")),
 		FileSize:              r.NewStyle().Foreground(lipgloss.Color("240")).Width(fileSizeWidth).Align(lipgloss.Right),
 		EmptyDirectory:        r.NewStyle().Foreground(lipgloss.Color("240")).PaddingLeft(paddingLeft).SetString("Bummer. No Files Found."),
+		UsageBar:              r.NewStyle().Foreground(lipgloss.Color("33")),
 	}
 }
 
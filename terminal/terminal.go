@@ -19,23 +19,36 @@ type TerminalModel struct {
 	err             error
 }
 
-func New(tarFile io.Reader, exportPath string) (TerminalModel, error) {
-	tb, _ := NewTextBox()
-	if len(exportPath) == 0 {
-		exportPath = tar.ExtractFolder
-	}
+func New(tarFile io.Reader, exportPath string, opts ...tar.ExtractOption) (TerminalModel, error) {
 	root, err := tar.Scan(tarFile, OnNewNode)
 	if err != nil {
 		return TerminalModel{}, fmt.Errorf("error on scanning tar file: %s", err)
 	}
+	return newTerminal(root, exportPath, opts...), nil
+}
+
+// NewImage works like New with a container image archive, its layers are browsed as a single root filesystem (see tar.ScanImage)
+func NewImage(imageFile io.Reader, stackOpts []tar.StackOption, exportPath string, opts ...tar.ExtractOption) (TerminalModel, error) {
+	root, err := tar.ScanImage(imageFile, OnNewNode, stackOpts...)
+	if err != nil {
+		return TerminalModel{}, fmt.Errorf("error on scanning image file: %s", err)
+	}
+	return newTerminal(root, exportPath, opts...), nil
+}
+
+func newTerminal(root *listerNode, exportPath string, opts ...tar.ExtractOption) TerminalModel {
+	tb, _ := NewTextBox()
+	if len(exportPath) == 0 {
+		exportPath = tar.ExtractFolder
+	}
 	return TerminalModel{
 		textBox:         tb,
-		directoryLister: NewLister(root, exportPath),
+		directoryLister: NewLister(root, exportPath, opts...),
 		CurrentView:     directoryLister,
 		KeyMap:          DefaultKeyMap(),
 		quitting:        false,
 		err:             nil,
-	}, nil
+	}
 }
 
 // Init initializes the file picker model.
@@ -60,7 +73,11 @@ func (m TerminalModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case setViewTypeMsg:
 		m.CurrentView = msg
 		if m.CurrentView == fileReader {
-			return m, ReadData(m.directoryLister.GetSelectedFile().GetData())
+			data, err := m.directoryLister.GetSelectedFile().ReadData()
+			if err != nil {
+				return m, func() tea.Msg { return errMsg(fmt.Errorf("error on reading file: %s", err)) }
+			}
+			return m, ReadData(data)
 		}
 	}
 
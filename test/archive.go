@@ -5,14 +5,18 @@ import (
 	"bytes"
 	"io/fs"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
 
 type File struct {
-	Name string
-	Mode fs.FileMode
-	Body string
+	Name     string
+	Mode     fs.FileMode
+	Body     string
+	Type     byte   // Typeflag of header, guessed from Name if empty
+	Linkname string // Target of links
+	ModTime  time.Time
 }
 
 // CreateArchive for tests, this function will return a tar archive buffer based on given files
@@ -23,9 +27,12 @@ func CreateArchive(t *testing.T, files []File) *bytes.Buffer {
 
 	for _, file := range files {
 		hdr := &tar.Header{
-			Name: file.Name,
-			Mode: int64(file.Mode),
-			Size: int64(len(file.Body)),
+			Name:     file.Name,
+			Mode:     int64(file.Mode),
+			Size:     int64(len(file.Body)),
+			Typeflag: file.Type,
+			Linkname: file.Linkname,
+			ModTime:  file.ModTime,
 		}
 		require.Nil(t, tw.WriteHeader(hdr))
 		_, err := tw.Write([]byte(file.Body))